@@ -0,0 +1,135 @@
+//  Copyright (c) 2024 Couchbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 		http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bleve
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/blevesearch/bleve/v2/search"
+)
+
+// Types of the aggregations computed from facet results.
+const (
+	AggregationTerms         = "terms"
+	AggregationRange         = "range"
+	AggregationDateRange     = "date_range"
+	AggregationStats         = "stats"
+	AggregationHistogram     = "histogram"
+	AggregationDateHistogram = "date_histogram"
+)
+
+// AggregationBucket is a bucket of an aggregation, identified by its
+// key.  Range and histogram buckets also report their bounds, From
+// being inclusive and To exclusive, either being nil when unbounded.
+type AggregationBucket struct {
+	Key   string      `json:"key"`
+	From  interface{} `json:"from,omitempty"`
+	To    interface{} `json:"to,omitempty"`
+	Count int         `json:"count"`
+}
+
+// Aggregation presents a facet result with the same shape whatever
+// the kind of facet: stats aggregations report their Stats, all the
+// others their Buckets.
+type Aggregation struct {
+	Type    string               `json:"type"`
+	Field   string               `json:"field"`
+	Total   int                  `json:"total"`
+	Missing int                  `json:"missing"`
+	Other   int                  `json:"other"`
+	Buckets []*AggregationBucket `json:"buckets,omitempty"`
+	Stats   *search.NumericStats `json:"stats,omitempty"`
+}
+
+// Aggregations converts the facets of the result to aggregations,
+// keyed by facet name.
+func (sr *SearchResult) Aggregations() map[string]*Aggregation {
+	if len(sr.Facets) == 0 {
+		return nil
+	}
+	rv := make(map[string]*Aggregation, len(sr.Facets))
+	for name, fr := range sr.Facets {
+		rv[name] = newAggregation(fr)
+	}
+	return rv
+}
+
+func newAggregation(fr *search.FacetResult) *Aggregation {
+	rv := &Aggregation{
+		Field:   fr.Field,
+		Total:   fr.Total,
+		Missing: fr.Missing,
+		Other:   fr.Other,
+	}
+	switch {
+	case fr.Stats != nil:
+		rv.Type = AggregationStats
+		rv.Stats = fr.Stats
+	case fr.NumericRanges != nil:
+		rv.Type = AggregationRange
+		for _, nr := range fr.NumericRanges {
+			b := &AggregationBucket{Key: nr.Name, Count: nr.Count}
+			if nr.Min != nil {
+				b.From = *nr.Min
+			}
+			if nr.Max != nil {
+				b.To = *nr.Max
+			}
+			rv.Buckets = append(rv.Buckets, b)
+		}
+	case fr.DateRanges != nil:
+		rv.Type = AggregationDateRange
+		for _, dr := range fr.DateRanges {
+			b := &AggregationBucket{Key: dr.Name, Count: dr.Count}
+			if dr.Start != nil {
+				b.From = *dr.Start
+			}
+			if dr.End != nil {
+				b.To = *dr.End
+			}
+			rv.Buckets = append(rv.Buckets, b)
+		}
+	case fr.Histogram != nil:
+		rv.Type = AggregationHistogram
+		for _, hb := range fr.Histogram {
+			rv.Buckets = append(rv.Buckets, &AggregationBucket{
+				Key:   strconv.FormatFloat(hb.Min, 'g', -1, 64),
+				From:  hb.Min,
+				To:    hb.Max,
+				Count: hb.Count,
+			})
+		}
+	case fr.DateHistogram != nil:
+		rv.Type = AggregationDateHistogram
+		for _, db := range fr.DateHistogram {
+			rv.Buckets = append(rv.Buckets, &AggregationBucket{
+				Key:   db.Start.Format(time.RFC3339),
+				From:  db.Start.Format(time.RFC3339),
+				To:    db.End.Format(time.RFC3339),
+				Count: db.Count,
+			})
+		}
+	default:
+		rv.Type = AggregationTerms
+		for _, tf := range fr.Terms.Terms() {
+			rv.Buckets = append(rv.Buckets, &AggregationBucket{
+				Key:   tf.Term,
+				Count: tf.Count,
+			})
+		}
+	}
+	return rv
+}
@@ -0,0 +1,101 @@
+//  Copyright (c) 2024 Couchbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 		http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package soundex implements a token filter replacing each term with
+// its American Soundex code, so that words which sound alike, such as
+// "Smith" and "Smyth", produce the same term.  The algorithm is meant
+// for English names, and only the ASCII letters of a term are coded.
+package soundex
+
+import (
+	"github.com/blevesearch/bleve/v2/analysis"
+	"github.com/blevesearch/bleve/v2/registry"
+)
+
+// Name is the name used to register SoundexFilter in the bleve registry
+const Name = "soundex"
+
+type SoundexFilter struct {
+}
+
+func NewSoundexFilter() *SoundexFilter {
+	return &SoundexFilter{}
+}
+
+func (f *SoundexFilter) Filter(input analysis.TokenStream) analysis.TokenStream {
+	for _, token := range input {
+		if code := soundex(token.Term); code != nil {
+			token.Term = code
+		}
+	}
+	return input
+}
+
+func SoundexFilterConstructor(config map[string]interface{}, cache *registry.Cache) (analysis.TokenFilter, error) {
+	return NewSoundexFilter(), nil
+}
+
+func init() {
+	registry.RegisterTokenFilter(Name, SoundexFilterConstructor)
+}
+
+// codes maps the lower case letters to their soundex digit,
+// vowels map to 0 and separate letters sharing a digit,
+// while h and w, mapping to -1, do not
+var codes = [26]int8{
+	// a  b  c  d  e  f  g   h  i  j  k  l  m
+	0, 1, 2, 3, 0, 1, 2, -1, 0, 2, 2, 4, 5,
+	// n  o  p  q  r  s  t  u  v   w  x  y  z
+	5, 0, 1, 2, 6, 2, 3, 0, 1, -1, 2, 0, 2,
+}
+
+// soundex returns the four character code of the term, or nil
+// when the term contains no ASCII letter.
+func soundex(term []byte) []byte {
+	rv := make([]byte, 0, 4)
+	var last int8
+	for _, b := range term {
+		if b >= 'A' && b <= 'Z' {
+			b += 'a' - 'A'
+		}
+		if b < 'a' || b > 'z' {
+			continue
+		}
+		code := codes[b-'a']
+		if len(rv) == 0 {
+			rv = append(rv, b-('a'-'A'))
+			last = code
+			continue
+		}
+		switch {
+		case code == -1:
+			// h and w keep the previous code
+		case code == 0:
+			last = 0
+		case code != last:
+			rv = append(rv, '0'+byte(code))
+			last = code
+		}
+		if len(rv) == 4 {
+			break
+		}
+	}
+	if len(rv) == 0 {
+		return nil
+	}
+	for len(rv) < 4 {
+		rv = append(rv, '0')
+	}
+	return rv
+}
@@ -0,0 +1,59 @@
+//  Copyright (c) 2024 Couchbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 		http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package soundex
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/blevesearch/bleve/v2/analysis"
+)
+
+func TestSoundexFilter(t *testing.T) {
+	tests := []struct {
+		input  string
+		output string
+	}{
+		{input: "Robert", output: "R163"},
+		{input: "Rupert", output: "R163"},
+		{input: "smith", output: "S530"},
+		{input: "Smyth", output: "S530"},
+		{input: "Tymczak", output: "T522"},
+		{input: "Pfister", output: "P236"},
+		{input: "Ashcraft", output: "A261"},
+		{input: "Lee", output: "L000"},
+		{input: "o'Hara", output: "O600"},
+		{input: "123", output: "123"},
+		{input: "", output: ""},
+	}
+
+	for _, test := range tests {
+		inputTokenStream := analysis.TokenStream{
+			&analysis.Token{
+				Term: []byte(test.input),
+			},
+		}
+		expectedTokenStream := analysis.TokenStream{
+			&analysis.Token{
+				Term: []byte(test.output),
+			},
+		}
+		filter := NewSoundexFilter()
+		outputTokenStream := filter.Filter(inputTokenStream)
+		if !reflect.DeepEqual(outputTokenStream, expectedTokenStream) {
+			t.Errorf("expected %s got %s for %q", expectedTokenStream, outputTokenStream, test.input)
+		}
+	}
+}
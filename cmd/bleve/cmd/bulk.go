@@ -25,6 +25,7 @@ import (
 )
 
 var batchSize int
+var parseJSON bool
 
 // bulkCmd represents the bulk command
 var bulkCmd = &cobra.Command{
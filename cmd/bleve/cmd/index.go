@@ -15,16 +15,21 @@
 package cmd
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
+	"unicode/utf8"
 
+	"github.com/blevesearch/bleve/v2"
 	"github.com/spf13/cobra"
 )
 
-var keepDir, keepExt, parseJSON bool
+var keepDir, keepExt, indexParseJSON bool
+var extensions []string
 
 // indexCmd represents the index command
 var indexCmd = &cobra.Command{
@@ -38,79 +43,160 @@ var indexCmd = &cobra.Command{
 		if len(args) < 2 {
 			return fmt.Errorf("must specify at least one path")
 		}
-		for file := range handleArgs(args[1:]) {
-			var doc interface{}
-			// index the files
-			docID := file.filename
-			if !keepDir {
-				_, docID = filepath.Split(docID)
-			}
-			if !keepExt {
-				ext := filepath.Ext(docID)
-				docID = docID[0 : len(docID)-len(ext)]
-			}
-			doc = file.contents
-			var err error
-			if parseJSON {
-				err = json.Unmarshal(file.contents, &doc)
-				if err != nil {
-					return fmt.Errorf("error parsing JSON: %v", err)
-				}
-			}
-			fmt.Printf("Indexing: %s\n", docID)
-			err = idx.Index(docID, doc)
+		options := IndexDirectoryOptions{
+			Extensions: extensions,
+			KeepDir:    keepDir,
+			KeepExt:    keepExt,
+			ParseJSON:  indexParseJSON,
+		}
+		for _, path := range args[1:] {
+			count, err := IndexDirectory(idx, path, options)
 			if err != nil {
-				return fmt.Errorf("error indexing: %v", err)
+				return err
 			}
+			fmt.Printf("Indexed %d documents from %s\n", count, path)
 		}
 		return nil
 	},
 }
 
-type file struct {
-	filename string
-	contents []byte
+// IndexDirectoryOptions controls how IndexDirectory turns files
+// into documents.
+type IndexDirectoryOptions struct {
+	// Extensions restricts the files indexed to those having one of
+	// them, like "txt" or ".md", all files are indexed when empty.
+	Extensions []string
+	// KeepDir and KeepExt keep the directory and the extension of
+	// the path of a file, relative to the walked directory, in the
+	// ID of its document.
+	KeepDir bool
+	KeepExt bool
+	// ParseJSON parses the contents of every file as a JSON document,
+	// files with a .json extension are always parsed.  The contents of
+	// the other files are indexed as the content field.
+	ParseJSON bool
+	// BatchSize is the number of documents indexed per batch,
+	// 1000 when not positive.
+	BatchSize int
 }
 
-func handleArgs(args []string) chan file {
-	rv := make(chan file)
-	go getAllFiles(args, rv)
-	return rv
+// DefaultIndexDirectoryOptions names documents by the path of their
+// file relative to the walked directory.
+var DefaultIndexDirectoryOptions = IndexDirectoryOptions{
+	KeepDir: true,
+	KeepExt: true,
 }
 
-func getAllFiles(args []string, rv chan file) {
-	for _, arg := range args {
-		arg = filepath.Clean(arg)
-		err := filepath.Walk(arg, func(path string, finfo os.FileInfo, err error) error {
+// IndexDirectory walks the directory, or the single file, at path and
+// batch indexes each file as a document, skipping files which do not
+// look like text.  It returns the number of documents indexed.
+func IndexDirectory(i bleve.Index, path string, options IndexDirectoryOptions) (int, error) {
+	batchSize := options.BatchSize
+	if batchSize <= 0 {
+		batchSize = 1000
+	}
+
+	count := 0
+	batch := i.NewBatch()
+	root := filepath.Clean(path)
+	err := filepath.Walk(root, func(path string, finfo os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if finfo.IsDir() || !hasExtension(path, options.Extensions) {
+			return nil
+		}
+
+		contents, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if !isText(contents) {
+			log.Printf("Skipping binary file: %s", path)
+			return nil
+		}
+
+		filename, err := filepath.Rel(root, path)
+		if err != nil || filename == "." {
+			filename = filepath.Base(path)
+		}
+		docID := filepath.ToSlash(filename)
+		if !options.KeepDir {
+			docID = filepath.Base(filename)
+		}
+		if !options.KeepExt {
+			docID = strings.TrimSuffix(docID, filepath.Ext(docID))
+		}
+
+		var doc interface{}
+		if options.ParseJSON || strings.EqualFold(filepath.Ext(path), ".json") {
+			err = json.Unmarshal(contents, &doc)
 			if err != nil {
-				log.Print(err)
-				return err
+				return fmt.Errorf("error parsing JSON of %s: %v", path, err)
 			}
-			if finfo.IsDir() {
-				return nil
+		} else {
+			doc = map[string]interface{}{
+				"content": string(contents),
 			}
+		}
+		err = batch.Index(docID, doc)
+		if err != nil {
+			return fmt.Errorf("error indexing %s: %v", path, err)
+		}
+		count++
 
-			bytes, err := os.ReadFile(path)
+		if batch.Size() >= batchSize {
+			err = i.Batch(batch)
 			if err != nil {
-				log.Fatal(err)
-			}
-			rv <- file{
-				filename: filepath.Base(path),
-				contents: bytes,
+				return err
 			}
-			return nil
-		})
+			batch.Reset()
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	if batch.Size() > 0 {
+		err = i.Batch(batch)
 		if err != nil {
-			log.Fatal(err)
+			return 0, err
+		}
+	}
+	return count, nil
+}
+
+func hasExtension(path string, extensions []string) bool {
+	if len(extensions) == 0 {
+		return true
+	}
+	ext := filepath.Ext(path)
+	for _, e := range extensions {
+		if strings.EqualFold(ext, "."+strings.TrimPrefix(e, ".")) {
+			return true
 		}
 	}
-	close(rv)
+	return false
+}
+
+// isText reports whether the contents look like text, that is
+// valid UTF-8 without any NUL bytes in the first few kilobytes.
+func isText(contents []byte) bool {
+	head := contents
+	if len(head) > 8000 {
+		head = head[:8000]
+	}
+	if bytes.IndexByte(head, 0) >= 0 {
+		return false
+	}
+	return utf8.Valid(contents)
 }
 
 func init() {
 	RootCmd.AddCommand(indexCmd)
 
-	indexCmd.Flags().BoolVarP(&keepDir, "keepDir", "d", false, "Keep the directory in the document id.")
-	indexCmd.Flags().BoolVarP(&keepExt, "keepExt", "x", false, "Keep the extension in the document id.")
-	indexCmd.Flags().BoolVarP(&parseJSON, "json", "j", true, "Parse the contents as JSON.")
+	indexCmd.Flags().BoolVarP(&keepDir, "keepDir", "d", DefaultIndexDirectoryOptions.KeepDir, "Keep the directory in the document id.")
+	indexCmd.Flags().BoolVarP(&keepExt, "keepExt", "x", DefaultIndexDirectoryOptions.KeepExt, "Keep the extension in the document id.")
+	indexCmd.Flags().BoolVarP(&indexParseJSON, "json", "j", DefaultIndexDirectoryOptions.ParseJSON, "Parse the contents of all files as JSON, .json files always are, otherwise index them as the content field.")
+	indexCmd.Flags().StringSliceVarP(&extensions, "ext", "e", nil, "Only index files with these extensions, for example txt,md.")
 }
@@ -0,0 +1,100 @@
+// Copyright © 2024 Couchbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 		http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/blevesearch/bleve/v2"
+)
+
+func TestIndexDirectory(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"a/readme.md":  "bleve is a text indexing library",
+		"b/readme.md":  "the readme of another bleve project",
+		"notes.txt":    "bleve indexes plain text notes",
+		"image.png":    "\x89PNG\r\n\x1a\n\x00\x00",
+		"data/doc.bin": "binary\x00contents",
+	}
+	for name, contents := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		err := os.MkdirAll(filepath.Dir(path), 0700)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.WriteFile(path, []byte(contents), 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	idx, err := bleve.NewMemOnly(bleve.NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = idx.Close()
+	}()
+
+	count, err := IndexDirectory(idx, dir, DefaultIndexDirectoryOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 3 {
+		t.Errorf("expected 3 documents indexed, got %d", count)
+	}
+
+	res, err := idx.Search(bleve.NewSearchRequest(bleve.NewMatchQuery("bleve")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, hit := range res.Hits {
+		ids = append(ids, hit.ID)
+	}
+	sort.Strings(ids)
+	expected := []string{"a/readme.md", "b/readme.md", "notes.txt"}
+	if len(ids) != len(expected) {
+		t.Fatalf("expected hits %v, got %v", expected, ids)
+	}
+	for i := range expected {
+		if ids[i] != expected[i] {
+			t.Errorf("expected hits %v, got %v", expected, ids)
+			break
+		}
+	}
+
+	// extensions restrict the files indexed
+	idx2, err := bleve.NewMemOnly(bleve.NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = idx2.Close()
+	}()
+	options := DefaultIndexDirectoryOptions
+	options.Extensions = []string{"txt"}
+	count, err = IndexDirectory(idx2, dir, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("expected 1 document indexed for txt files, got %d", count)
+	}
+}
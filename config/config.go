@@ -55,6 +55,7 @@ import (
 	_ "github.com/blevesearch/bleve/v2/analysis/token/ngram"
 	_ "github.com/blevesearch/bleve/v2/analysis/token/reverse"
 	_ "github.com/blevesearch/bleve/v2/analysis/token/shingle"
+	_ "github.com/blevesearch/bleve/v2/analysis/token/soundex"
 	_ "github.com/blevesearch/bleve/v2/analysis/token/stop"
 	_ "github.com/blevesearch/bleve/v2/analysis/token/truncate"
 	_ "github.com/blevesearch/bleve/v2/analysis/token/unicodenorm"
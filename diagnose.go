@@ -0,0 +1,107 @@
+//  Copyright (c) 2024 Couchbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 		http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bleve
+
+import (
+	"context"
+
+	"github.com/blevesearch/bleve/v2/search/query"
+)
+
+// ClauseDiagnosis reports how many documents a single clause
+// of a query matches when searched on its own.  Part tells whether
+// the clause is in the query or in the filter of the request, and
+// Path locates it there, for example "must.conjuncts[1]".
+type ClauseDiagnosis struct {
+	Part    string      `json:"part"`
+	Path    string      `json:"path"`
+	Clause  query.Query `json:"clause"`
+	Matches uint64      `json:"matches"`
+}
+
+// DiagnoseQuery breaks the query and the filter of the request down
+// into the clauses of their compound queries, query string queries
+// included, and searches each clause on its own.  When a request
+// finds nothing, the clauses matching no document are the ones
+// eliminating all the results, and for must_not clauses, those
+// matching every document.
+func DiagnoseQuery(ctx context.Context, i Index, req *SearchRequest) ([]*ClauseDiagnosis, error) {
+	var rv []*ClauseDiagnosis
+	err := walkRequestClauses(req, func(part, path string, q query.Query) error {
+		req := NewSearchRequestOptions(q, 0, 0, false)
+		res, err := i.SearchInContext(ctx, req)
+		if err != nil {
+			return err
+		}
+		rv = append(rv, &ClauseDiagnosis{
+			Part:    part,
+			Path:    path,
+			Clause:  q,
+			Matches: res.Total,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rv, nil
+}
+
+// walkRequestClauses calls fn with each clause of the query and
+// then of the filter of the request, as walked by walkClauses, and
+// with the part of the request holding it, "query" or "filter".
+func walkRequestClauses(req *SearchRequest, fn func(part, path string, q query.Query) error) error {
+	for _, part := range []struct {
+		name string
+		q    query.Query
+	}{{"query", req.Query}, {"filter", req.Filter}} {
+		err := walkClauses("", part.q, func(path string, q query.Query) error {
+			return fn(part.name, path, q)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// walkClauses calls fn with each clause of the compound queries
+// of the query tree, query string queries included, and with the
+// path locating it in the tree.
+func walkClauses(path string, q query.Query, fn func(path string, q query.Query) error) error {
+	switch q := q.(type) {
+	case nil:
+		return nil
+	case *query.QueryStringQuery:
+		parsed, err := q.Parse()
+		if err != nil {
+			return err
+		}
+		return walkClauses(path, parsed, fn)
+	}
+	if !query.IsCompound(q) {
+		return fn(path, q)
+	}
+	return query.WalkChildren(q, func(name string, child query.Query) (query.Query, error) {
+		return child, walkClauses(joinDiagnosisPath(path, name), child, fn)
+	})
+}
+
+func joinDiagnosisPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
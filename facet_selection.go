@@ -0,0 +1,184 @@
+//  Copyright (c) 2024 Couchbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 		http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bleve
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/blevesearch/bleve/v2/search/query"
+)
+
+const (
+	// FacetModePostFilter computes the facets over the results
+	// narrowed by the selected facet buckets, the default.
+	FacetModePostFilter = "post_filter"
+	// FacetModePreFilter computes the facets over the results
+	// before narrowing them by the selected facet buckets.
+	FacetModePreFilter = "pre_filter"
+)
+
+// Select restricts the search results to the documents
+// falling in the named buckets of this facet.
+func (fr *FacetRequest) Select(buckets ...string) {
+	fr.Selected = append(fr.Selected, buckets...)
+}
+
+func (fr *FacetRequest) rangeNamed(name string) interface{} {
+	for _, nr := range fr.NumericRanges {
+		if nr.Name == name {
+			return nr
+		}
+	}
+	for _, dr := range fr.DateTimeRanges {
+		if dr.Name == name {
+			return dr
+		}
+	}
+	return nil
+}
+
+// selectionQuery returns a query matching the documents
+// in any of the selected buckets of the facet.
+func (fr *FacetRequest) selectionQuery() (query.Query, error) {
+	buckets := make([]query.Query, 0, len(fr.Selected))
+	for _, name := range fr.Selected {
+		if len(fr.NumericRanges) == 0 && len(fr.DateTimeRanges) == 0 {
+			q := query.NewTermQuery(name)
+			q.SetField(fr.Field)
+			buckets = append(buckets, q)
+			continue
+		}
+		switch r := fr.rangeNamed(name).(type) {
+		case *numericRange:
+			q := query.NewNumericRangeQuery(r.Min, r.Max)
+			q.SetField(fr.Field)
+			buckets = append(buckets, q)
+		case *dateTimeRange:
+			dateTimeParserName := defaultDateTimeParser
+			if r.DateTimeParser != "" {
+				dateTimeParserName = r.DateTimeParser
+			}
+			dateTimeParser, err := cache.DateTimeParserNamed(dateTimeParserName)
+			if err != nil {
+				return nil, err
+			}
+			start, end, err := r.ParseDates(dateTimeParser)
+			if err != nil {
+				return nil, err
+			}
+			q := query.NewDateRangeQuery(start, end)
+			q.SetField(fr.Field)
+			buckets = append(buckets, q)
+		default:
+			return nil, fmt.Errorf("selected range '%s' is not a range of the facet", name)
+		}
+	}
+	return query.NewDisjunctionQuery(buckets), nil
+}
+
+func (fr FacetsRequest) hasSelections() bool {
+	for _, facetRequest := range fr {
+		if len(facetRequest.Selected) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// withoutSelections returns a copy of the facets
+// without their selected buckets
+func (fr FacetsRequest) withoutSelections() FacetsRequest {
+	rv := make(FacetsRequest, len(fr))
+	for name, facetRequest := range fr {
+		facetRequestCopy := *facetRequest
+		facetRequestCopy.Selected = nil
+		rv[name] = &facetRequestCopy
+	}
+	return rv
+}
+
+// selectionQuery returns a query matching the documents in
+// one of the selected buckets of every facet with a selection.
+func (fr FacetsRequest) selectionQuery() (query.Query, error) {
+	names := make([]string, 0, len(fr))
+	for name, facetRequest := range fr {
+		if len(facetRequest.Selected) > 0 {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	selections := make([]query.Query, 0, len(names))
+	for _, name := range names {
+		q, err := fr[name].selectionQuery()
+		if err != nil {
+			return nil, fmt.Errorf("facet '%s': %v", name, err)
+		}
+		selections = append(selections, q)
+	}
+	return query.NewConjunctionQuery(selections), nil
+}
+
+// searchWithFacetSelections runs a search request having selected
+// facet buckets as a search filtered by the selection, computing
+// the facets before or after the selection as per its FacetMode.
+func searchWithFacetSelections(ctx context.Context, i Index, req *SearchRequest) (*SearchResult, error) {
+	selection, err := req.Facets.selectionQuery()
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := *req
+	filtered.Facets = req.Facets.withoutSelections()
+	if req.Filter != nil {
+		filtered.Filter = query.NewConjunctionQuery([]query.Query{req.Filter, selection})
+	} else {
+		filtered.Filter = selection
+	}
+	if req.FacetMode == FacetModePreFilter {
+		filtered.Facets = nil
+	}
+	rv, err := i.SearchInContext(ctx, &filtered)
+	if err != nil {
+		return nil, err
+	}
+	if req.Explain {
+		rv.Request = req
+	}
+	if req.FacetMode != FacetModePreFilter {
+		return rv, nil
+	}
+
+	facetsOnly := *req
+	facetsOnly.Facets = req.Facets.withoutSelections()
+	facetsOnly.Size = 0
+	facetsOnly.From = 0
+	facetsOnly.Highlight = nil
+	facetsOnly.Fields = nil
+	facetsOnly.Explain = false
+	facetsOnly.IncludeLocations = false
+	facetsOnly.SearchAfter = nil
+	facetsOnly.SearchBefore = nil
+	facets, err := i.SearchInContext(ctx, &facetsOnly)
+	if err != nil {
+		return nil, err
+	}
+	rv.Facets = facets.Facets
+	rv.Took += facets.Took
+	rv.Cost += facets.Cost
+	return rv, nil
+}
@@ -0,0 +1,116 @@
+//  Copyright (c) 2024 Couchbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 		http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+type BulkDeleteRequest struct {
+	IDs []string `json:"ids"`
+}
+
+// DocBulkDeleteHandler deletes a list of documents
+// from an index using a single batch.
+type DocBulkDeleteHandler struct {
+	defaultIndexName string
+	IndexNameLookup  varLookupFunc
+}
+
+func NewDocBulkDeleteHandler(defaultIndexName string) *DocBulkDeleteHandler {
+	return &DocBulkDeleteHandler{
+		defaultIndexName: defaultIndexName,
+	}
+}
+
+func (h *DocBulkDeleteHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+
+	// find the index to operate on
+	var indexName string
+	if h.IndexNameLookup != nil {
+		indexName = h.IndexNameLookup(req)
+	}
+	if indexName == "" {
+		indexName = h.defaultIndexName
+	}
+	index := IndexByName(indexName)
+	if index == nil {
+		showError(w, req, fmt.Sprintf("no such index '%s'", indexName), 404)
+		return
+	}
+
+	// read the request body
+	requestBody, err := io.ReadAll(req.Body)
+	if err != nil {
+		showError(w, req, fmt.Sprintf("error reading request body: %v", err), 400)
+		return
+	}
+
+	var bulkDelete BulkDeleteRequest
+	err = json.Unmarshal(requestBody, &bulkDelete)
+	if err != nil {
+		showError(w, req, fmt.Sprintf("error parsing bulk delete request: %v", err), 400)
+		return
+	}
+
+	var toDelete []string
+	seen := make(map[string]struct{}, len(bulkDelete.IDs))
+	notFound := 0
+	for _, docID := range bulkDelete.IDs {
+		if docID == "" {
+			showError(w, req, "document id cannot be empty", 400)
+			return
+		}
+		if _, exists := seen[docID]; exists {
+			continue
+		}
+		seen[docID] = struct{}{}
+
+		doc, err := index.Document(docID)
+		if err != nil {
+			showError(w, req, fmt.Sprintf("error looking up document '%s': %v", docID, err), 500)
+			return
+		}
+		if doc == nil {
+			notFound++
+			continue
+		}
+		toDelete = append(toDelete, docID)
+	}
+
+	deleted := len(toDelete)
+	if deleted > 0 {
+		err = deleteVersionedDocs(index, toDelete...)
+		if err != nil {
+			showError(w, req, fmt.Sprintf("error deleting documents: %v", err), 500)
+			return
+		}
+		markIndexWritten(indexName)
+	}
+
+	rv := struct {
+		Status   string `json:"status"`
+		Deleted  int    `json:"deleted"`
+		NotFound int    `json:"not_found"`
+	}{
+		Status:   "ok",
+		Deleted:  deleted,
+		NotFound: notFound,
+	}
+	mustEncode(w, rv)
+}
@@ -57,11 +57,12 @@ func (h *DocDeleteHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	err := index.Delete(docID)
+	err := deleteVersionedDocs(index, docID)
 	if err != nil {
 		showError(w, req, fmt.Sprintf("error deleting document '%s': %v", docID, err), 500)
 		return
 	}
+	markIndexWritten(indexName)
 
 	rv := struct {
 		Status string `json:"status"`
@@ -74,12 +74,20 @@ func (h *DocGetHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	version, err := docVersion(idx, docID)
+	if err != nil {
+		showError(w, req, fmt.Sprintf("error reading version of document '%s': %v", docID, err), 500)
+		return
+	}
+
 	rv := struct {
-		ID     string                 `json:"id"`
-		Fields map[string]interface{} `json:"fields"`
+		ID      string                 `json:"id"`
+		Version uint64                 `json:"version,omitempty"`
+		Fields  map[string]interface{} `json:"fields"`
 	}{
-		ID:     docID,
-		Fields: map[string]interface{}{},
+		ID:      docID,
+		Version: version,
+		Fields:  map[string]interface{}{},
 	}
 
 	doc.VisitFields(func(field index.Field) {
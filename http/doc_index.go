@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 )
 
 type DocIndexHandler struct {
@@ -74,16 +75,36 @@ func (h *DocIndexHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	err = index.Index(docID, doc)
+	// version=N only indexes the document if it is at version N, see
+	// doc_version.go for which writes are versioned
+	var expectedVersion *uint64
+	if versionStr := req.FormValue("version"); versionStr != "" {
+		version, err := strconv.ParseUint(versionStr, 10, 64)
+		if err != nil {
+			showError(w, req, fmt.Sprintf("error parsing version value: %v", err), 400)
+			return
+		}
+		expectedVersion = &version
+	}
+
+	version, err := indexVersionedDoc(index, docID, doc, expectedVersion)
+	if err == errVersionConflict {
+		showError(w, req, fmt.Sprintf("version conflict, document '%s' is at "+
+			"version %d, not %d", docID, version, *expectedVersion), 409)
+		return
+	}
 	if err != nil {
 		showError(w, req, fmt.Sprintf("error indexing document '%s': %v", docID, err), 500)
 		return
 	}
+	markIndexWritten(indexName)
 
 	rv := struct {
-		Status string `json:"status"`
+		Status  string `json:"status"`
+		Version uint64 `json:"version"`
 	}{
-		Status: "ok",
+		Status:  "ok",
+		Version: version,
 	}
 	mustEncode(w, rv)
 }
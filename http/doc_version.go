@@ -0,0 +1,123 @@
+//  Copyright (c) 2024 Couchbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 		http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+
+	"github.com/blevesearch/bleve/v2"
+)
+
+// The version of a document is the number of times it was written
+// by a DocIndexHandler, documents never written by one are at
+// version 0.  Versions are kept in the internal storage of the index
+// and updated in the same batch as the document, writes checking and
+// incrementing them are serialized by the version lock of the index.
+// Deleting a document through a DocDeleteHandler or a
+// DocBulkDeleteHandler deletes its version in the same batch, so a
+// document created again starts over at version 0.
+//
+// Only writes through these handlers are versioned.  Documents indexed
+// or deleted directly through the bleve.Index (or by any other handler)
+// leave their version untouched, so applications mixing both kinds of
+// writes cannot rely on the version to detect concurrent updates.
+var docVersionLocksLock sync.Mutex
+var docVersionLocks map[bleve.Index]*sync.Mutex
+
+var errVersionConflict = errors.New("version conflict")
+
+// docVersionLock returns the lock serializing the versioned writes
+// to the index.
+func docVersionLock(idx bleve.Index) *sync.Mutex {
+	docVersionLocksLock.Lock()
+	defer docVersionLocksLock.Unlock()
+
+	if docVersionLocks == nil {
+		docVersionLocks = make(map[bleve.Index]*sync.Mutex)
+	}
+	rv := docVersionLocks[idx]
+	if rv == nil {
+		rv = &sync.Mutex{}
+		docVersionLocks[idx] = rv
+	}
+	return rv
+}
+
+func forgetDocVersionLock(idx bleve.Index) {
+	docVersionLocksLock.Lock()
+	delete(docVersionLocks, idx)
+	docVersionLocksLock.Unlock()
+}
+
+// docVersionKey is the internal key of the version of a document,
+// prefixed to keep clear of the internal keys of applications.
+func docVersionKey(docID string) []byte {
+	return []byte("_bleve_http_version/" + docID)
+}
+
+func docVersion(idx bleve.Index, docID string) (uint64, error) {
+	val, err := idx.GetInternal(docVersionKey(docID))
+	if err != nil || val == nil {
+		return 0, err
+	}
+	return strconv.ParseUint(string(val), 10, 64)
+}
+
+// indexVersionedDoc indexes the document, incrementing its version,
+// and returns the new version.  When expected is not nil the document
+// is only indexed if it is at that version, otherwise the current
+// version is returned with errVersionConflict.
+func indexVersionedDoc(idx bleve.Index, docID string, doc interface{},
+	expected *uint64) (uint64, error) {
+	lock := docVersionLock(idx)
+	lock.Lock()
+	defer lock.Unlock()
+
+	current, err := docVersion(idx, docID)
+	if err != nil {
+		return 0, err
+	}
+	if expected != nil && *expected != current {
+		return current, errVersionConflict
+	}
+
+	batch := idx.NewBatch()
+	err = batch.Index(docID, doc)
+	if err != nil {
+		return 0, err
+	}
+	batch.SetInternal(docVersionKey(docID), []byte(strconv.FormatUint(current+1, 10)))
+	err = idx.Batch(batch)
+	if err != nil {
+		return 0, err
+	}
+	return current + 1, nil
+}
+
+// deleteVersionedDocs deletes the documents along with their versions.
+func deleteVersionedDocs(idx bleve.Index, docIDs ...string) error {
+	lock := docVersionLock(idx)
+	lock.Lock()
+	defer lock.Unlock()
+
+	batch := idx.NewBatch()
+	for _, docID := range docIDs {
+		batch.Delete(docID)
+		batch.DeleteInternal(docVersionKey(docID))
+	}
+	return idx.Batch(batch)
+}
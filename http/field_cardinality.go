@@ -0,0 +1,102 @@
+//  Copyright (c) 2024 Couchbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 		http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// FieldCardinalityHandler returns the number of distinct terms
+// indexed for a field.  The count is exact, it is obtained by
+// walking the field dictionary, not estimated.  As it counts
+// terms, it matches the number of distinct values only for
+// fields indexed with the keyword analyzer.
+type FieldCardinalityHandler struct {
+	defaultIndexName string
+	IndexNameLookup  varLookupFunc
+}
+
+func NewFieldCardinalityHandler(defaultIndexName string) *FieldCardinalityHandler {
+	return &FieldCardinalityHandler{
+		defaultIndexName: defaultIndexName,
+	}
+}
+
+func (h *FieldCardinalityHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	// find the index to operate on
+	var indexName string
+	if h.IndexNameLookup != nil {
+		indexName = h.IndexNameLookup(req)
+	}
+	if indexName == "" {
+		indexName = h.defaultIndexName
+	}
+	idx := IndexByName(indexName)
+	if idx == nil {
+		showError(w, req, fmt.Sprintf("no such index '%s'", indexName), 404)
+		return
+	}
+
+	field := req.FormValue("field")
+	if field == "" {
+		showError(w, req, "field is required", 400)
+		return
+	}
+
+	dict, err := idx.FieldDict(field)
+	if err != nil {
+		showError(w, req, fmt.Sprintf("error reading field dictionary: %v", err), 500)
+		return
+	}
+	defer func() {
+		_ = dict.Close()
+	}()
+
+	// walking the dictionary of a large field takes a while, stop
+	// when the client goes away
+	ctx := req.Context()
+	var cardinality uint64
+	entry, err := dict.Next()
+	for err == nil && entry != nil {
+		if err = ctx.Err(); err != nil {
+			break
+		}
+		cardinality++
+		entry, err = dict.Next()
+	}
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		showError(w, req, fmt.Sprintf("counting terms timed out: %v", err), http.StatusGatewayTimeout)
+		return
+	case errors.Is(err, context.Canceled):
+		showError(w, req, fmt.Sprintf("counting terms canceled: %v", err), StatusClientClosedRequest)
+		return
+	case err != nil:
+		showError(w, req, fmt.Sprintf("error reading field dictionary: %v", err), 500)
+		return
+	}
+
+	rv := struct {
+		Field       string `json:"field"`
+		Cardinality uint64 `json:"cardinality"`
+	}{
+		Field:       field,
+		Cardinality: cardinality,
+	}
+	mustEncode(w, rv)
+}
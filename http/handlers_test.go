@@ -16,13 +16,25 @@ package http
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
 	"io"
+	"math"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/index/scorch"
+	"github.com/blevesearch/bleve/v2/index/scorch/mergeplan"
+	index "github.com/blevesearch/bleve_index_api"
 )
 
 func docIDLookup(req *http.Request) string {
@@ -73,6 +85,9 @@ func TestHandlers(t *testing.T) {
 	docDeleteHandler.IndexNameLookup = indexNameLookup
 	docDeleteHandler.DocIDLookup = docIDLookup
 
+	docBulkDeleteHandler := NewDocBulkDeleteHandler("")
+	docBulkDeleteHandler.IndexNameLookup = indexNameLookup
+
 	searchHandler := NewSearchHandler("")
 	searchHandler.IndexNameLookup = indexNameLookup
 
@@ -125,7 +140,7 @@ func TestHandlers(t *testing.T) {
 			Method:       "PUT",
 			Body:         []byte("{}"),
 			Status:       http.StatusBadRequest,
-			ResponseBody: []byte(`index name is required`),
+			ResponseBody: []byte(`{"error":{"message":"index name is required","code":400}}`),
 		},
 		{
 			Desc:    "create index invalid json",
@@ -168,7 +183,7 @@ func TestHandlers(t *testing.T) {
 			Path:         "/get",
 			Method:       "GET",
 			Status:       http.StatusBadRequest,
-			ResponseBody: []byte(`index name is required`),
+			ResponseBody: []byte(`{"error":{"message":"index name is required","code":400}}`),
 		},
 		{
 			Desc:         "create another index",
@@ -207,7 +222,7 @@ func TestHandlers(t *testing.T) {
 			Path:         "/delete",
 			Method:       "DELETE",
 			Status:       http.StatusBadRequest,
-			ResponseBody: []byte(`index name is required`),
+			ResponseBody: []byte(`{"error":{"message":"index name is required","code":400}}`),
 		},
 		{
 			Desc:    "list indexes after delete",
@@ -232,7 +247,7 @@ func TestHandlers(t *testing.T) {
 			},
 			Body:         []byte(`{"name":"a","body":"test","rating":7,"created":"2014-11-26","former_ratings":[3,4,2]}`),
 			Status:       http.StatusOK,
-			ResponseBody: []byte(`{"status":"ok"}`),
+			ResponseBody: []byte(`{"status":"ok","version":1}`),
 		},
 		{
 			Desc:    "index doc invalid index",
@@ -245,7 +260,7 @@ func TestHandlers(t *testing.T) {
 			},
 			Body:         []byte(`{"name":"a","body":"test","rating":7,"created":"2014-11-26","former_ratings":[3,4,2]}`),
 			Status:       http.StatusNotFound,
-			ResponseBody: []byte(`no such index 'tix'`),
+			ResponseBody: []byte(`{"error":{"message":"no such index 'tix'","code":404}}`),
 		},
 		{
 			Desc:    "index doc missing ID",
@@ -257,7 +272,7 @@ func TestHandlers(t *testing.T) {
 			},
 			Body:         []byte(`{"name":"a","body":"test","rating":7,"created":"2014-11-26","former_ratings":[3,4,2]}`),
 			Status:       http.StatusBadRequest,
-			ResponseBody: []byte(`document id cannot be empty`),
+			ResponseBody: []byte(`{"error":{"message":"document id cannot be empty","code":400}}`),
 		},
 		{
 			Desc:    "doc count",
@@ -279,7 +294,7 @@ func TestHandlers(t *testing.T) {
 				"indexName": []string{"tix"},
 			},
 			Status:       http.StatusNotFound,
-			ResponseBody: []byte(`no such index 'tix'`),
+			ResponseBody: []byte(`{"error":{"message":"no such index 'tix'","code":404}}`),
 		},
 		{
 			Desc:    "doc get",
@@ -307,7 +322,7 @@ func TestHandlers(t *testing.T) {
 				"docID":     []string{"a"},
 			},
 			Status:       http.StatusNotFound,
-			ResponseBody: []byte(`no such index 'tix'`),
+			ResponseBody: []byte(`{"error":{"message":"no such index 'tix'","code":404}}`),
 		},
 		{
 			Desc:    "doc get missing ID",
@@ -318,7 +333,7 @@ func TestHandlers(t *testing.T) {
 				"indexName": []string{"ti1"},
 			},
 			Status:       http.StatusBadRequest,
-			ResponseBody: []byte(`document id cannot be empty`),
+			ResponseBody: []byte(`{"error":{"message":"document id cannot be empty","code":400}}`),
 		},
 		{
 			Desc:    "index another doc",
@@ -331,7 +346,7 @@ func TestHandlers(t *testing.T) {
 			},
 			Body:         []byte(`{"name":"b","body":"del"}`),
 			Status:       http.StatusOK,
-			ResponseBody: []byte(`{"status":"ok"}`),
+			ResponseBody: []byte(`{"status":"ok","version":1}`),
 		},
 		{
 			Desc:    "doc count again",
@@ -366,7 +381,7 @@ func TestHandlers(t *testing.T) {
 				"docID":     []string{"b"},
 			},
 			Status:       http.StatusNotFound,
-			ResponseBody: []byte(`no such index 'tix'`),
+			ResponseBody: []byte(`{"error":{"message":"no such index 'tix'","code":404}}`),
 		},
 		{
 			Desc:    "delete doc missing docID",
@@ -377,7 +392,7 @@ func TestHandlers(t *testing.T) {
 				"indexName": []string{"ti1"},
 			},
 			Status:       http.StatusBadRequest,
-			ResponseBody: []byte(`document id cannot be empty`),
+			ResponseBody: []byte(`{"error":{"message":"document id cannot be empty","code":400}}`),
 		},
 		{
 			Desc:    "doc get",
@@ -417,6 +432,49 @@ func TestHandlers(t *testing.T) {
 				`"id":"a"`:       true,
 			},
 		},
+		{
+			Desc:    "search within max result window",
+			Handler: searchHandler,
+			Path:    "/ti1/search",
+			Method:  "POST",
+			Params: url.Values{
+				"indexName": []string{"ti1"},
+			},
+			Body: []byte(`{
+				"from": 9990,
+				"size": 10,
+				"query": {
+					"field": "body",
+					"match": "test"
+				}
+			}`),
+			Status: http.StatusOK,
+			ResponseMatch: map[string]bool{
+				`"total_hits":1`: true,
+			},
+		},
+		{
+			Desc:    "search beyond max result window",
+			Handler: searchHandler,
+			Path:    "/ti1/search",
+			Method:  "POST",
+			Params: url.Values{
+				"indexName": []string{"ti1"},
+			},
+			Body: []byte(`{
+				"from": 9995,
+				"size": 10,
+				"query": {
+					"field": "body",
+					"match": "test"
+				}
+			}`),
+			Status: http.StatusBadRequest,
+			ResponseMatch: map[string]bool{
+				`result window is too large`: true,
+				`search_after`:               true,
+			},
+		},
 		{
 			Desc:    "search index doesn't exist",
 			Handler: searchHandler,
@@ -436,7 +494,7 @@ func TestHandlers(t *testing.T) {
 				}
 			}`),
 			Status:       http.StatusNotFound,
-			ResponseBody: []byte(`no such index 'tix'`),
+			ResponseBody: []byte(`{"error":{"message":"no such index 'tix'","code":404}}`),
 		},
 		{
 			Desc:    "search invalid json",
@@ -449,7 +507,8 @@ func TestHandlers(t *testing.T) {
 			Body:   []byte(`{`),
 			Status: http.StatusBadRequest,
 			ResponseMatch: map[string]bool{
-				`error parsing query`: true,
+				`{"error":{"message":"error parsing query`: true,
+				`"code":400}}`: true,
 			},
 		},
 		{
@@ -498,7 +557,7 @@ func TestHandlers(t *testing.T) {
 				"indexName": []string{"tix"},
 			},
 			Status:       http.StatusNotFound,
-			ResponseBody: []byte(`no such index 'tix'`),
+			ResponseBody: []byte(`{"error":{"message":"no such index 'tix'","code":404}}`),
 		},
 		{
 			Desc:    "create alias",
@@ -632,7 +691,7 @@ func TestHandlers(t *testing.T) {
 				"add": ["ti99"]
 			}`),
 			Status:       http.StatusBadRequest,
-			ResponseBody: []byte(`error updating alias: index named 'ti99' does not exist`),
+			ResponseBody: []byte(`{"error":{"message":"error updating alias: index named 'ti99' does not exist","code":400}}`),
 		},
 		{
 			Desc:    "update alias remove ti6",
@@ -656,7 +715,82 @@ func TestHandlers(t *testing.T) {
 				"remove": ["ti98"]
 			}`),
 			Status:       http.StatusBadRequest,
-			ResponseBody: []byte(`error updating alias: index named 'ti98' does not exist`),
+			ResponseBody: []byte(`{"error":{"message":"error updating alias: index named 'ti98' does not exist","code":400}}`),
+		},
+		{
+			Desc:    "index doc to bulk delete",
+			Handler: docIndexHandler,
+			Path:    "/ti1/c",
+			Method:  "PUT",
+			Params: url.Values{
+				"indexName": []string{"ti1"},
+				"docID":     []string{"c"},
+			},
+			Body:         []byte(`{"name":"c","body":"bulk"}`),
+			Status:       http.StatusOK,
+			ResponseBody: []byte(`{"status":"ok","version":1}`),
+		},
+		{
+			Desc:    "index another doc to bulk delete",
+			Handler: docIndexHandler,
+			Path:    "/ti1/d",
+			Method:  "PUT",
+			Params: url.Values{
+				"indexName": []string{"ti1"},
+				"docID":     []string{"d"},
+			},
+			Body:         []byte(`{"name":"d","body":"bulk"}`),
+			Status:       http.StatusOK,
+			ResponseBody: []byte(`{"status":"ok","version":1}`),
+		},
+		{
+			Desc:    "bulk delete docs",
+			Handler: docBulkDeleteHandler,
+			Path:    "/ti1/bulk-delete",
+			Method:  "POST",
+			Params: url.Values{
+				"indexName": []string{"ti1"},
+			},
+			Body:         []byte(`{"ids":["c","d","missing","c"]}`),
+			Status:       http.StatusOK,
+			ResponseBody: []byte(`{"status":"ok","deleted":2,"not_found":1}`),
+		},
+		{
+			Desc:    "doc count after bulk delete",
+			Handler: docCountHandler,
+			Path:    "/ti1/count",
+			Method:  "GET",
+			Params: url.Values{
+				"indexName": []string{"ti1"},
+			},
+			Status:       http.StatusOK,
+			ResponseBody: []byte(`{"status":"ok","count":1}`),
+		},
+		{
+			Desc:    "bulk delete invalid json",
+			Handler: docBulkDeleteHandler,
+			Path:    "/ti1/bulk-delete",
+			Method:  "POST",
+			Params: url.Values{
+				"indexName": []string{"ti1"},
+			},
+			Body:   []byte(`{`),
+			Status: http.StatusBadRequest,
+			ResponseMatch: map[string]bool{
+				`error parsing bulk delete request`: true,
+			},
+		},
+		{
+			Desc:    "bulk delete invalid index",
+			Handler: docBulkDeleteHandler,
+			Path:    "/tix/bulk-delete",
+			Method:  "POST",
+			Params: url.Values{
+				"indexName": []string{"tix"},
+			},
+			Body:         []byte(`{"ids":["a"]}`),
+			Status:       http.StatusNotFound,
+			ResponseBody: []byte(`{"error":{"message":"no such index 'tix'","code":404}}`),
 		},
 	}
 
@@ -700,3 +834,1768 @@ func TestHandlers(t *testing.T) {
 		}
 	}
 }
+
+func TestOptimizeIndexHandler(t *testing.T) {
+	basePath := "testoptimize"
+	defer func() {
+		err := os.RemoveAll(basePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	idx, err := bleve.New(basePath, bleve.NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	RegisterIndexName("optimize", idx)
+	defer func() {
+		UnregisterIndexByName("optimize")
+		err := idx.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	advanced, err := idx.Advanced()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// index several batches, waiting for each one to be persisted
+	// so that every batch ends up in its own file segment
+	for i := 0; i < 3; i++ {
+		batch := idx.NewBatch()
+		for j := 0; j < 10; j++ {
+			err = batch.Index(fmt.Sprintf("doc-%d-%d", i, j), map[string]interface{}{
+				"body": fmt.Sprintf("text %d %d", i, j),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		err = idx.Batch(batch)
+		if err != nil {
+			t.Fatal(err)
+		}
+		deadline := time.Now().Add(10 * time.Second)
+		for advanced.StatsMap()["num_root_memorysegments"].(uint64) > 0 {
+			if time.Now().After(deadline) {
+				t.Fatal("timed out waiting for segments to be persisted")
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	handler := NewOptimizeIndexHandler("optimize")
+	record := httptest.NewRecorder()
+	req := &http.Request{
+		Method: "POST",
+		URL:    &url.URL{Path: "/optimize"},
+		Body:   io.NopCloser(bytes.NewBuffer(nil)),
+	}
+	handler.ServeHTTP(record, req)
+	if record.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", record.Code, record.Body)
+	}
+
+	var rv struct {
+		Before segmentStats `json:"before"`
+		After  segmentStats `json:"after"`
+	}
+	err = json.Unmarshal(record.Body.Bytes(), &rv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the background merger may already have merged some of the
+	// segments, so only check that a single one is left
+	if rv.After.Segments != 1 || rv.After.Segments > rv.Before.Segments {
+		t.Errorf("expected a single segment after optimizing, before %d, after %d",
+			rv.Before.Segments, rv.After.Segments)
+	}
+
+	count, err := idx.DocCount()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 30 {
+		t.Errorf("expected 30 documents after optimizing, got %d", count)
+	}
+}
+
+// bleveIndex names the embedded index apart from its Index method
+type bleveIndex = bleve.Index
+
+// mergingIndex is an index whose force merges fail with err
+type mergingIndex struct {
+	bleveIndex
+	err error
+}
+
+func (i *mergingIndex) Advanced() (index.Index, error) {
+	advanced, err := i.bleveIndex.Advanced()
+	if err != nil {
+		return nil, err
+	}
+	return &mergingAdvancedIndex{Index: advanced, err: i.err}, nil
+}
+
+type mergingAdvancedIndex struct {
+	index.Index
+	err error
+}
+
+func (i *mergingAdvancedIndex) ForceMerge(ctx context.Context, mo *mergeplan.MergePlanOptions) error {
+	return i.err
+}
+
+func TestOptimizeIndexHandlerErrors(t *testing.T) {
+	idx, err := bleve.NewMemOnly(bleve.NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err := idx.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	tests := []struct {
+		err  error
+		code int
+		resp string
+	}{
+		{
+			err:  scorch.ErrForceMergeInProgress,
+			code: http.StatusConflict,
+			resp: `{"error":{"message":"index 'optimizeerr' is already being optimized","code":409}}`,
+		},
+		{
+			err:  fmt.Errorf("merging err: %w", scorch.ErrForceMergeInProgress),
+			code: http.StatusConflict,
+			resp: `{"error":{"message":"index 'optimizeerr' is already being optimized","code":409}}`,
+		},
+		{
+			err:  fmt.Errorf("disk full"),
+			code: http.StatusInternalServerError,
+			resp: `{"error":{"message":"error optimizing index: disk full","code":500}}`,
+		},
+	}
+
+	handler := NewOptimizeIndexHandler("optimizeerr")
+	for _, test := range tests {
+		RegisterIndexName("optimizeerr", &mergingIndex{bleveIndex: idx, err: test.err})
+		record := httptest.NewRecorder()
+		handler.ServeHTTP(record, &http.Request{
+			Method: "POST",
+			URL:    &url.URL{Path: "/optimizeerr/_optimize"},
+			Body:   io.NopCloser(bytes.NewBuffer(nil)),
+		})
+		UnregisterIndexByName("optimizeerr")
+		if record.Code != test.code {
+			t.Errorf("%v: expected status %d, got %d: %s", test.err, test.code, record.Code, record.Body)
+		}
+		if got := strings.TrimSpace(record.Body.String()); got != test.resp {
+			t.Errorf("%v: expected %s, got %s", test.err, test.resp, got)
+		}
+	}
+}
+
+func TestSearchHandlerCancellation(t *testing.T) {
+	idx, err := bleve.NewMemOnly(bleve.NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	RegisterIndexName("cancel", idx)
+	defer func() {
+		UnregisterIndexByName("cancel")
+		err := idx.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+	err = idx.Index("a", map[string]interface{}{"name": "marty"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler := NewSearchHandler("cancel")
+	search := func(ctx context.Context, rawQuery string) *httptest.ResponseRecorder {
+		record := httptest.NewRecorder()
+		req := &http.Request{
+			Method: "POST",
+			URL:    &url.URL{Path: "/search", RawQuery: rawQuery},
+			Body:   io.NopCloser(bytes.NewBufferString(`{"query":{"match":"marty"}}`)),
+		}
+		handler.ServeHTTP(record, req.WithContext(ctx))
+		return record
+	}
+
+	// the client went away before the search completed
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	start := time.Now()
+	record := search(ctx, "")
+	if record.Code != StatusClientClosedRequest {
+		t.Errorf("expected status %d, got %d: %s", StatusClientClosedRequest, record.Code, record.Body)
+	}
+	if took := time.Since(start); took > time.Second {
+		t.Errorf("expected canceled search to return promptly, took %s", took)
+	}
+
+	// the search ran out of time
+	record = search(context.Background(), "timeout=1ns")
+	if record.Code != http.StatusGatewayTimeout {
+		t.Errorf("expected status %d, got %d: %s", http.StatusGatewayTimeout, record.Code, record.Body)
+	}
+
+	// a default timeout is applied to requests without one
+	handler.Timeout = time.Nanosecond
+	record = search(context.Background(), "")
+	if record.Code != http.StatusGatewayTimeout {
+		t.Errorf("expected status %d, got %d: %s", http.StatusGatewayTimeout, record.Code, record.Body)
+	}
+	handler.Timeout = time.Minute
+	record = search(context.Background(), "")
+	if record.Code != http.StatusOK {
+		t.Errorf("expected status %d, got %d: %s", http.StatusOK, record.Code, record.Body)
+	}
+}
+
+func TestSearchHandlerExplainTree(t *testing.T) {
+	idx, err := bleve.NewMemOnly(bleve.NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	RegisterIndexName("explain", idx)
+	defer func() {
+		UnregisterIndexByName("explain")
+		err := idx.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+	err = idx.Index("a", map[string]interface{}{"name": "marty"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler := NewSearchHandler("explain")
+	search := func(rawQuery string) *httptest.ResponseRecorder {
+		record := httptest.NewRecorder()
+		req := &http.Request{
+			Method: "POST",
+			URL:    &url.URL{Path: "/search", RawQuery: rawQuery},
+			Body:   io.NopCloser(bytes.NewBufferString(`{"query":{"term":"marty","field":"name"}}`)),
+		}
+		handler.ServeHTTP(record, req)
+		return record
+	}
+
+	record := search("explain_format=tree")
+	if record.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, record.Code, record.Body)
+	}
+	var rv struct {
+		Total            uint64            `json:"total_hits"`
+		ExplanationTrees map[string]string `json:"explanation_trees"`
+	}
+	err = json.Unmarshal(record.Body.Bytes(), &rv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rv.Total != 1 {
+		t.Errorf("expected 1 hit, got %d", rv.Total)
+	}
+	tree := rv.ExplanationTrees["a"]
+	for _, expected := range []string{
+		"fieldWeight(name:marty in a), product of:\n",
+		"\n  1.000000 tf(termFreq(name:marty)=1\n",
+		"\n  1.000000 fieldNorm(field=name, doc=a)\n",
+		"\n  0.306853 idf(docFreq=1, maxDocs=1)\n",
+	} {
+		if !strings.Contains(tree, expected) {
+			t.Errorf("expected explanation tree to contain %q, got:\n%s", expected, tree)
+		}
+	}
+
+	record = search("explain_format=xml")
+	if record.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d: %s", http.StatusBadRequest, record.Code, record.Body)
+	}
+}
+
+func TestValidateMappingHandler(t *testing.T) {
+	handler := NewValidateMappingHandler()
+
+	tests := []struct {
+		body   string
+		code   int
+		valid  bool
+		errors int
+	}{
+		{
+			body:  `{"default_mapping":{"properties":{"name":{"fields":[{"name":"name","type":"text"}]}}}}`,
+			code:  http.StatusOK,
+			valid: true,
+		},
+		{
+			body: `{"default_mapping":{"properties":{` +
+				`"name":{"fields":[{"name":"name","type":"text","analyzer":"no-such-analyzer"}]},` +
+				`"vec":{"fields":[{"name":"vec","type":"vector","dims":100000}]}}}}`,
+			code:   http.StatusOK,
+			errors: 2,
+		},
+		{
+			body: `{"default_mapping":`,
+			code: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tests {
+		record := httptest.NewRecorder()
+		req := &http.Request{
+			Method: "POST",
+			URL:    &url.URL{Path: "/validate-mapping"},
+			Body:   io.NopCloser(strings.NewReader(test.body)),
+		}
+		handler.ServeHTTP(record, req)
+		if record.Code != test.code {
+			t.Fatalf("expected status %d, got %d: %s", test.code, record.Code, record.Body)
+		}
+		if test.code != http.StatusOK {
+			continue
+		}
+		var rv struct {
+			Valid  bool     `json:"valid"`
+			Errors []string `json:"errors"`
+		}
+		err := json.Unmarshal(record.Body.Bytes(), &rv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rv.Valid != test.valid || len(rv.Errors) != test.errors {
+			t.Errorf("expected valid %t with %d errors, got %t with %v",
+				test.valid, test.errors, rv.Valid, rv.Errors)
+		}
+	}
+}
+
+func TestTermsHandler(t *testing.T) {
+	idx, err := bleve.NewMemOnly(bleve.NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	RegisterIndexName("terms", idx)
+	defer func() {
+		UnregisterIndexByName("terms")
+		err := idx.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	docs := map[string]string{
+		"a": "search engine",
+		"b": "search library",
+		"c": "search engine internals",
+		"d": "segment merging",
+	}
+	for id, body := range docs {
+		err = idx.Index(id, map[string]interface{}{"body": body})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		query    string
+		code     int
+		expected []termCount
+	}{
+		{
+			query: "field=body&top=2",
+			code:  http.StatusOK,
+			expected: []termCount{
+				{Term: "search", Count: 3},
+				{Term: "engine", Count: 2},
+			},
+		},
+		{
+			query: "field=body&prefix=se",
+			code:  http.StatusOK,
+			expected: []termCount{
+				{Term: "search", Count: 3},
+				{Term: "segment", Count: 1},
+			},
+		},
+		{
+			query: "field=body&top=0",
+			code:  http.StatusBadRequest,
+		},
+		{
+			query: "top=2",
+			code:  http.StatusBadRequest,
+		},
+	}
+
+	handler := NewTermsHandler("terms")
+	for _, test := range tests {
+		record := httptest.NewRecorder()
+		req := &http.Request{
+			Method: "GET",
+			URL:    &url.URL{Path: "/terms", RawQuery: test.query},
+		}
+		handler.ServeHTTP(record, req)
+		if record.Code != test.code {
+			t.Fatalf("%s: expected status %d, got %d: %s", test.query, test.code, record.Code, record.Body)
+		}
+		if test.code != http.StatusOK {
+			continue
+		}
+		var rv struct {
+			Terms []termCount `json:"terms"`
+		}
+		err := json.Unmarshal(record.Body.Bytes(), &rv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(rv.Terms, test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.query, test.expected, rv.Terms)
+		}
+	}
+
+	handler.MaxScanned = 1
+	record := httptest.NewRecorder()
+	req := &http.Request{
+		Method: "GET",
+		URL:    &url.URL{Path: "/terms", RawQuery: "field=body"},
+	}
+	handler.ServeHTTP(record, req)
+	if !strings.Contains(record.Body.String(), `"truncated":true`) {
+		t.Errorf("expected a truncated response, got %s", record.Body)
+	}
+}
+
+func TestSearchHandlerGeoJSON(t *testing.T) {
+	im := bleve.NewIndexMapping()
+	im.DefaultMapping.AddFieldMappingsAt("location", bleve.NewGeoPointFieldMapping())
+	idx, err := bleve.NewMemOnly(im)
+	if err != nil {
+		t.Fatal(err)
+	}
+	RegisterIndexName("geojson", idx)
+	defer func() {
+		UnregisterIndexByName("geojson")
+		err := idx.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	err = idx.Index("a", map[string]interface{}{
+		"name":     "brewery",
+		"location": map[string]interface{}{"lon": -122.4, "lat": 37.8},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = idx.Index("b", map[string]interface{}{"name": "no location"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler := NewSearchHandler("geojson")
+	for _, test := range []struct {
+		body  string
+		query string
+	}{
+		{
+			body:  `{"query":{"match_all":{}},"fields":["name"]}`,
+			query: "format=geojson&geo_field=location",
+		},
+		{
+			body:  `{"query":{"match_all":{}},"fields":["*"]}`,
+			query: "format=geojson",
+		},
+	} {
+		record := httptest.NewRecorder()
+		req := &http.Request{
+			Method: "POST",
+			URL:    &url.URL{Path: "/search", RawQuery: test.query},
+			Body:   io.NopCloser(strings.NewReader(test.body)),
+		}
+		handler.ServeHTTP(record, req)
+		if record.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", record.Code, record.Body)
+		}
+
+		var rv struct {
+			Type     string `json:"type"`
+			Features []struct {
+				Type     string `json:"type"`
+				ID       string `json:"id"`
+				Geometry struct {
+					Type        string    `json:"type"`
+					Coordinates []float64 `json:"coordinates"`
+				} `json:"geometry"`
+				Properties map[string]interface{} `json:"properties"`
+			} `json:"features"`
+		}
+		err = json.Unmarshal(record.Body.Bytes(), &rv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rv.Type != "FeatureCollection" || len(rv.Features) != 1 {
+			t.Fatalf("expected a collection with one feature, got %s", record.Body)
+		}
+		feature := rv.Features[0]
+		if feature.Type != "Feature" || feature.ID != "a" ||
+			feature.Geometry.Type != "Point" {
+			t.Errorf("unexpected feature %s", record.Body)
+		}
+		if len(feature.Geometry.Coordinates) != 2 ||
+			math.Abs(feature.Geometry.Coordinates[0]+122.4) > 1e-6 ||
+			math.Abs(feature.Geometry.Coordinates[1]-37.8) > 1e-6 {
+			t.Errorf("expected coordinates [-122.4, 37.8], got %v", feature.Geometry.Coordinates)
+		}
+		expected := map[string]interface{}{"name": "brewery"}
+		if !reflect.DeepEqual(feature.Properties, expected) {
+			t.Errorf("expected properties %v, got %v", expected, feature.Properties)
+		}
+	}
+}
+
+func TestSearchHandlerLeadingWildcard(t *testing.T) {
+	idx, err := bleve.NewMemOnly(bleve.NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	RegisterIndexName("wildcard", idx)
+	defer func() {
+		UnregisterIndexByName("wildcard")
+		err := idx.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+	err = idx.Index("a", map[string]interface{}{"name": "marty"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		body  string
+		query string
+		code  int
+	}{
+		{
+			body: `{"query":{"wildcard":"*ty","field":"name"}}`,
+			code: http.StatusBadRequest,
+		},
+		{
+			body: `{"query":{"query":"name:*ty"}}`,
+			code: http.StatusBadRequest,
+		},
+		{
+			body: `{"query":{"regexp":".*ty","field":"name"}}`,
+			code: http.StatusBadRequest,
+		},
+		{
+			body: `{"query":{"match_all":{}},"filter":{"wildcard":"?arty","field":"name"}}`,
+			code: http.StatusBadRequest,
+		},
+		{
+			body: `{"query":{"wildcard":"mar*","field":"name"}}`,
+			code: http.StatusOK,
+		},
+		{
+			body:  `{"query":{"wildcard":"*ty","field":"name"}}`,
+			query: "allow_leading_wildcard=true",
+			code:  http.StatusOK,
+		},
+	}
+
+	handler := NewSearchHandler("wildcard")
+	for _, test := range tests {
+		record := httptest.NewRecorder()
+		req := &http.Request{
+			Method: "POST",
+			URL:    &url.URL{Path: "/search", RawQuery: test.query},
+			Body:   io.NopCloser(strings.NewReader(test.body)),
+		}
+		handler.ServeHTTP(record, req)
+		if record.Code != test.code {
+			t.Errorf("%s: expected status %d, got %d: %s", test.body, test.code, record.Code, record.Body)
+			continue
+		}
+		if test.code == http.StatusOK &&
+			!strings.Contains(record.Body.String(), `"total_hits":1`) {
+			t.Errorf("%s: expected one hit, got %s", test.body, record.Body)
+		}
+	}
+}
+
+func TestFieldCardinalityHandler(t *testing.T) {
+	im := bleve.NewIndexMapping()
+	categoryMapping := bleve.NewKeywordFieldMapping()
+	im.DefaultMapping.AddFieldMappingsAt("category", categoryMapping)
+	idx, err := bleve.NewMemOnly(im)
+	if err != nil {
+		t.Fatal(err)
+	}
+	RegisterIndexName("cardinality", idx)
+	defer func() {
+		UnregisterIndexByName("cardinality")
+		err := idx.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	categories := []string{"books", "home garden", "books", "music", "home garden"}
+	for i, category := range categories {
+		err = idx.Index(fmt.Sprintf("doc-%d", i), map[string]interface{}{"category": category})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	handler := NewFieldCardinalityHandler("cardinality")
+	record := httptest.NewRecorder()
+	req := &http.Request{
+		Method: "GET",
+		URL:    &url.URL{Path: "/cardinality", RawQuery: "field=category"},
+	}
+	handler.ServeHTTP(record, req)
+	if record.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", record.Code, record.Body)
+	}
+	var rv struct {
+		Cardinality uint64 `json:"cardinality"`
+	}
+	err = json.Unmarshal(record.Body.Bytes(), &rv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rv.Cardinality != 3 {
+		t.Errorf("expected cardinality 3, got %d", rv.Cardinality)
+	}
+
+	record = httptest.NewRecorder()
+	req = &http.Request{
+		Method: "GET",
+		URL:    &url.URL{Path: "/cardinality"},
+	}
+	handler.ServeHTTP(record, req)
+	if record.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 without a field, got %d", record.Code)
+	}
+
+	// the walk stops once the client has gone away
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	record = httptest.NewRecorder()
+	req = (&http.Request{
+		Method: "GET",
+		URL:    &url.URL{Path: "/cardinality", RawQuery: "field=category"},
+	}).WithContext(ctx)
+	handler.ServeHTTP(record, req)
+	if record.Code != StatusClientClosedRequest {
+		t.Errorf("expected status %d once canceled, got %d: %s", StatusClientClosedRequest, record.Code, record.Body)
+	}
+}
+
+func TestSearchHandlerDiagnose(t *testing.T) {
+	idx, err := bleve.NewMemOnly(bleve.NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	RegisterIndexName("diagnose", idx)
+	defer func() {
+		UnregisterIndexByName("diagnose")
+		err := idx.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+	err = idx.Index("a", map[string]interface{}{"name": "marty"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler := NewSearchHandler("diagnose")
+	record := httptest.NewRecorder()
+	req := &http.Request{
+		Method: "POST",
+		URL:    &url.URL{Path: "/search", RawQuery: "diagnose=true"},
+		Body: io.NopCloser(strings.NewReader(`{"query":{"conjuncts":[` +
+			`{"term":"marty","field":"name"},{"term":"doc","field":"name"}]}}`)),
+	}
+	handler.ServeHTTP(record, req)
+	if record.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", record.Code, record.Body)
+	}
+	var rv struct {
+		Diagnosis []struct {
+			Part    string `json:"part"`
+			Path    string `json:"path"`
+			Matches uint64 `json:"matches"`
+		} `json:"diagnosis"`
+	}
+	err = json.Unmarshal(record.Body.Bytes(), &rv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rv.Diagnosis) != 2 ||
+		rv.Diagnosis[0].Part != "query" || rv.Diagnosis[0].Path != "conjuncts[0]" || rv.Diagnosis[0].Matches != 1 ||
+		rv.Diagnosis[1].Part != "query" || rv.Diagnosis[1].Path != "conjuncts[1]" || rv.Diagnosis[1].Matches != 0 {
+		t.Errorf("unexpected diagnosis %s", record.Body)
+	}
+}
+
+func TestSearchHandlerProfileFilterOnly(t *testing.T) {
+	idx, err := bleve.NewMemOnly(bleve.NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	RegisterIndexName("profilefilter", idx)
+	defer func() {
+		UnregisterIndexByName("profilefilter")
+		err := idx.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+	for id, name := range map[string]string{"a": "marty", "b": "steve"} {
+		err = idx.Index(id, map[string]interface{}{"name": name})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	handler := NewSearchHandler("profilefilter")
+	record := httptest.NewRecorder()
+	req := &http.Request{
+		Method: "POST",
+		URL:    &url.URL{Path: "/search", RawQuery: "profile=true"},
+		Body:   io.NopCloser(strings.NewReader(`{"filter":{"term":"marty","field":"name"}}`)),
+	}
+	handler.ServeHTTP(record, req)
+	if record.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", record.Code, record.Body)
+	}
+	var rv struct {
+		Total   uint64 `json:"total_hits"`
+		Profile struct {
+			Clauses []struct {
+				Part    string `json:"part"`
+				Matches uint64 `json:"matches"`
+			} `json:"clauses"`
+		} `json:"profile"`
+	}
+	err = json.Unmarshal(record.Body.Bytes(), &rv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rv.Total != 1 || len(rv.Profile.Clauses) != 1 ||
+		rv.Profile.Clauses[0].Part != "filter" || rv.Profile.Clauses[0].Matches != 1 {
+		t.Errorf("unexpected profile %s", record.Body)
+	}
+}
+
+func TestIndexInfoHandler(t *testing.T) {
+	basePath := "testindexinfo"
+	defer func() {
+		err := os.RemoveAll(basePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	idx, err := bleve.New(basePath, bleve.NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	RegisterIndexName("info", idx)
+	defer func() {
+		UnregisterIndexByName("info")
+		err := idx.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	type indexInfo struct {
+		DocCount   uint64     `json:"doc_count"`
+		Generation *uint64    `json:"generation"`
+		LastWrite  *time.Time `json:"last_write"`
+	}
+	handler := NewIndexInfoHandler("info")
+	getInfo := func() indexInfo {
+		record := httptest.NewRecorder()
+		req := &http.Request{
+			Method: "GET",
+			URL:    &url.URL{Path: "/index-info"},
+		}
+		handler.ServeHTTP(record, req)
+		if record.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", record.Code, record.Body)
+		}
+		var rv indexInfo
+		err := json.Unmarshal(record.Body.Bytes(), &rv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return rv
+	}
+
+	before := getInfo()
+	if before.LastWrite != nil {
+		t.Errorf("expected no last write before indexing, got %v", before.LastWrite)
+	}
+	if before.Generation == nil {
+		t.Fatalf("expected the generation of a scorch index")
+	}
+
+	docIndexHandler := NewDocIndexHandler("info")
+	docIndexHandler.DocIDLookup = docIDLookup
+	start := time.Now()
+	record := httptest.NewRecorder()
+	req := &http.Request{
+		Method: "PUT",
+		URL:    &url.URL{Path: "/info/a", RawQuery: "docID=a"},
+		Body:   io.NopCloser(strings.NewReader(`{"name": "marty"}`)),
+	}
+	docIndexHandler.ServeHTTP(record, req)
+	if record.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", record.Code, record.Body)
+	}
+
+	after := getInfo()
+	if after.DocCount != 1 {
+		t.Errorf("expected doc count 1, got %d", after.DocCount)
+	}
+	if after.LastWrite == nil || after.LastWrite.Before(start) {
+		t.Errorf("expected last write after %v, got %v", start, after.LastWrite)
+	}
+	if after.Generation == nil || *after.Generation <= *before.Generation {
+		t.Errorf("expected generation to advance from %d, got %v", *before.Generation, after.Generation)
+	}
+}
+
+func TestSearchHandlerMaxClauseCount(t *testing.T) {
+	idx, err := bleve.NewMemOnly(bleve.NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	RegisterIndexName("clauses", idx)
+	defer func() {
+		UnregisterIndexByName("clauses")
+		err := idx.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+	err = idx.Index("a", map[string]interface{}{"name": "marty"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	terms := func(n int) string {
+		clauses := make([]string, n)
+		for i := range clauses {
+			clauses[i] = fmt.Sprintf(`{"term":"t%d","field":"name"}`, i)
+		}
+		return strings.Join(clauses, ",")
+	}
+
+	handler := NewSearchHandler("clauses")
+	handler.MaxClauseCount = 4
+	tests := []struct {
+		body string
+		code int
+	}{
+		{
+			body: `{"query":{"should":{"disjuncts":[` + terms(4) + `]}}}`,
+			code: http.StatusOK,
+		},
+		{
+			body: `{"query":{"should":{"disjuncts":[` + terms(5) + `]}}}`,
+			code: http.StatusBadRequest,
+		},
+		{
+			// nested clauses and the filter count too
+			body: `{"query":{"conjuncts":[{"disjuncts":[` + terms(2) + `]},` + terms(1) + `]},` +
+				`"filter":{"must_not":{"disjuncts":[` + terms(2) + `]}}}`,
+			code: http.StatusBadRequest,
+		},
+		{
+			body: `{"query":{"query":"t1 t2 t3 t4 t5"}}`,
+			code: http.StatusBadRequest,
+		},
+	}
+	for _, test := range tests {
+		record := httptest.NewRecorder()
+		req := &http.Request{
+			Method: "POST",
+			URL:    &url.URL{Path: "/search"},
+			Body:   io.NopCloser(strings.NewReader(test.body)),
+		}
+		handler.ServeHTTP(record, req)
+		if record.Code != test.code {
+			t.Errorf("%s: expected status %d, got %d: %s", test.body, test.code, record.Code, record.Body)
+		}
+		if test.code == http.StatusBadRequest &&
+			!strings.Contains(record.Body.String(), "query has too many clauses") {
+			t.Errorf("%s: expected a clause count error, got %s", test.body, record.Body)
+		}
+	}
+}
+
+func TestSearchHandlerDefaultFields(t *testing.T) {
+	idx, err := bleve.NewMemOnly(bleve.NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	RegisterIndexName("defaultfields", idx)
+	defer func() {
+		UnregisterIndexByName("defaultfields")
+		err := idx.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+	err = idx.Index("a", map[string]interface{}{
+		"title":   "release notes",
+		"content": "the search engine got faster",
+		"author":  "marty",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler := NewSearchHandler("defaultfields")
+	handler.DefaultFields = []string{"title", "content"}
+	tests := []struct {
+		body   string
+		fields map[string]interface{}
+	}{
+		{
+			body: `{"query":{"match_all":{}}}`,
+			fields: map[string]interface{}{
+				"title":   "release notes",
+				"content": "the search engine got faster",
+			},
+		},
+		{
+			body: `{"query":{"match_all":{}},"fields":["author"]}`,
+			fields: map[string]interface{}{
+				"author": "marty",
+			},
+		},
+		{
+			body:   `{"query":{"match_all":{}},"fields":[]}`,
+			fields: nil,
+		},
+	}
+	for _, test := range tests {
+		record := httptest.NewRecorder()
+		req := &http.Request{
+			Method: "POST",
+			URL:    &url.URL{Path: "/search"},
+			Body:   io.NopCloser(strings.NewReader(test.body)),
+		}
+		handler.ServeHTTP(record, req)
+		if record.Code != http.StatusOK {
+			t.Fatalf("%s: expected status 200, got %d: %s", test.body, record.Code, record.Body)
+		}
+		var res bleve.SearchResult
+		err = json.Unmarshal(record.Body.Bytes(), &res)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(res.Hits) != 1 {
+			t.Fatalf("%s: expected 1 hit, got %d", test.body, len(res.Hits))
+		}
+		if !reflect.DeepEqual(res.Hits[0].Fields, test.fields) {
+			t.Errorf("%s: expected fields %v, got %v", test.body, test.fields, res.Hits[0].Fields)
+		}
+	}
+}
+
+func TestDocIndexHandlerVersion(t *testing.T) {
+	idx, err := bleve.NewMemOnly(bleve.NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	RegisterIndexName("versions", idx)
+	defer func() {
+		UnregisterIndexByName("versions")
+		err := idx.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	handler := NewDocIndexHandler("versions")
+	handler.DocIDLookup = docIDLookup
+	tests := []struct {
+		version string
+		body    string
+		code    int
+		resp    string
+	}{
+		{
+			// a new document is at version 0
+			version: "0",
+			body:    `{"name":"marty"}`,
+			code:    http.StatusOK,
+			resp:    `{"status":"ok","version":1}`,
+		},
+		{
+			// a stale version is rejected
+			version: "0",
+			body:    `{"name":"lost update"}`,
+			code:    http.StatusConflict,
+			resp:    `{"error":{"message":"version conflict, document 'a' is at version 1, not 0","code":409}}`,
+		},
+		{
+			version: "1",
+			body:    `{"name":"marty mchale"}`,
+			code:    http.StatusOK,
+			resp:    `{"status":"ok","version":2}`,
+		},
+		{
+			// writes without a version always succeed
+			body: `{"name":"marty schoch"}`,
+			code: http.StatusOK,
+			resp: `{"status":"ok","version":3}`,
+		},
+		{
+			version: "two",
+			body:    `{"name":"marty"}`,
+			code:    http.StatusBadRequest,
+		},
+	}
+	for _, test := range tests {
+		query := url.Values{"docID": []string{"a"}}
+		if test.version != "" {
+			query.Set("version", test.version)
+		}
+		record := httptest.NewRecorder()
+		req := &http.Request{
+			Method: "PUT",
+			URL:    &url.URL{Path: "/versions/a", RawQuery: query.Encode()},
+			Body:   io.NopCloser(strings.NewReader(test.body)),
+		}
+		handler.ServeHTTP(record, req)
+		if record.Code != test.code {
+			t.Errorf("version %s: expected status %d, got %d: %s", test.version, test.code, record.Code, record.Body)
+		}
+		if test.resp != "" && strings.TrimSpace(record.Body.String()) != test.resp {
+			t.Errorf("version %s: expected %s, got %s", test.version, test.resp, record.Body)
+		}
+	}
+
+	res, err := idx.Search(bleve.NewSearchRequest(bleve.NewMatchQuery("schoch")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Total != 1 {
+		t.Errorf("expected the last accepted write to be indexed, got %d hits", res.Total)
+	}
+}
+
+func TestDocIndexHandlerArbitraryFields(t *testing.T) {
+	idx, err := bleve.NewMemOnly(bleve.NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	RegisterIndexName("arbitrary", idx)
+	defer func() {
+		UnregisterIndexByName("arbitrary")
+		err := idx.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// no schema is declared, every field is picked up by the dynamic mapping
+	handler := NewDocIndexHandler("arbitrary")
+	handler.DocIDLookup = docIDLookup
+	record := httptest.NewRecorder()
+	req := &http.Request{
+		Method: "PUT",
+		URL:    &url.URL{Path: "/arbitrary/a", RawQuery: "docID=a"},
+		Body: io.NopCloser(strings.NewReader(`{
+			"content": "a general purpose indexer",
+			"team": "search",
+			"meta": {"owner": "marty", "tags": ["beta", "internal"]},
+			"rating": 4.5
+		}`)),
+	}
+	handler.ServeHTTP(record, req)
+	if record.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", record.Code, record.Body)
+	}
+
+	searches := []string{
+		`{"query":{"match":"indexer","field":"content"}}`,
+		`{"query":{"match":"search","field":"team"}}`,
+		`{"query":{"match":"marty","field":"meta.owner"}}`,
+		`{"query":{"match":"internal","field":"meta.tags"}}`,
+		`{"query":{"min":4,"max":5,"field":"rating"}}`,
+	}
+	searchHandler := NewSearchHandler("arbitrary")
+	for _, body := range searches {
+		record := httptest.NewRecorder()
+		req := &http.Request{
+			Method: "POST",
+			URL:    &url.URL{Path: "/search"},
+			Body:   io.NopCloser(strings.NewReader(body)),
+		}
+		searchHandler.ServeHTTP(record, req)
+		if record.Code != http.StatusOK {
+			t.Fatalf("%s: expected status 200, got %d: %s", body, record.Code, record.Body)
+		}
+		var res bleve.SearchResult
+		err = json.Unmarshal(record.Body.Bytes(), &res)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Total != 1 {
+			t.Errorf("%s: expected 1 hit, got %d", body, res.Total)
+		}
+	}
+}
+
+func TestSnapshotIndexHandler(t *testing.T) {
+	basePath := "testsnapshot"
+	defer func() {
+		err := os.RemoveAll(basePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	idx, err := bleve.New(basePath+string(os.PathSeparator)+"live", bleve.NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	RegisterIndexName("live", idx)
+	defer func() {
+		UnregisterIndexByName("live")
+		err := idx.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// keep writing batches of 10 documents during the snapshot
+	indexBatch := func(n int) error {
+		batch := idx.NewBatch()
+		for j := 0; j < 10; j++ {
+			err := batch.Index(fmt.Sprintf("doc-%d-%d", n, j), map[string]interface{}{
+				"batch": fmt.Sprintf("b%d", n),
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return idx.Batch(batch)
+	}
+	for n := 0; n < 5; n++ {
+		err = indexBatch(n)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	stop := make(chan struct{})
+	writerErr := make(chan error, 1)
+	go func() {
+		for n := 5; ; n++ {
+			select {
+			case <-stop:
+				writerErr <- nil
+				return
+			default:
+			}
+			if err := indexBatch(n); err != nil {
+				writerErr <- err
+				return
+			}
+		}
+	}()
+
+	handler := NewSnapshotIndexHandler(basePath, "live")
+	handler.SnapshotNameLookup = func(req *http.Request) string {
+		return "backup"
+	}
+	record := httptest.NewRecorder()
+	req := &http.Request{
+		Method: "POST",
+		URL:    &url.URL{Path: "/snapshot"},
+	}
+	handler.ServeHTTP(record, req)
+	close(stop)
+	if err := <-writerErr; err != nil {
+		t.Fatal(err)
+	}
+	if record.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", record.Code, record.Body)
+	}
+	var rv struct {
+		Path     string `json:"path"`
+		DocCount uint64 `json:"doc_count"`
+	}
+	err = json.Unmarshal(record.Body.Bytes(), &rv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	snapshot, err := bleve.Open(rv.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err := snapshot.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+	docCount, err := snapshot.DocCount()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if docCount != rv.DocCount || docCount < 50 {
+		t.Errorf("expected at least 50 docs, as reported %d, got %d", rv.DocCount, docCount)
+	}
+
+	// batches are either entirely in the snapshot or not at all
+	sr := bleve.NewSearchRequest(bleve.NewMatchAllQuery())
+	sr.AddFacet("batches", bleve.NewFacetRequest("batch", 100000))
+	res, err := snapshot.Search(sr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, term := range res.Facets["batches"].Terms.Terms() {
+		if term.Count != 10 {
+			t.Errorf("expected 10 docs of batch %s, got %d", term.Term, term.Count)
+		}
+	}
+
+	// snapshots are not overwritten
+	record = httptest.NewRecorder()
+	handler.ServeHTTP(record, req)
+	if record.Code != http.StatusConflict {
+		t.Errorf("expected status 409 for an existing snapshot, got %d", record.Code)
+	}
+
+	// a directory claimed by a concurrent snapshot is not copied into
+	err = os.Mkdir(basePath+string(os.PathSeparator)+"claimed", 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler.SnapshotNameLookup = func(req *http.Request) string {
+		return "claimed"
+	}
+	record = httptest.NewRecorder()
+	handler.ServeHTTP(record, req)
+	if record.Code != http.StatusConflict {
+		t.Errorf("expected status 409 for a claimed snapshot, got %d", record.Code)
+	}
+	entries, err := os.ReadDir(basePath + string(os.PathSeparator) + "claimed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("expected the claimed snapshot to be left empty, got %d entries", len(entries))
+	}
+
+	// errors only name the snapshot, not where it is stored
+	missingPath, err := filepath.Abs(basePath + string(os.PathSeparator) + "missing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	missingHandler := NewSnapshotIndexHandler(missingPath, "live")
+	missingHandler.SnapshotNameLookup = handler.SnapshotNameLookup
+	record = httptest.NewRecorder()
+	missingHandler.ServeHTTP(record, req)
+	if record.Code != http.StatusInternalServerError {
+		t.Errorf("expected status 500 for a missing base path, got %d", record.Code)
+	}
+	if !strings.Contains(record.Body.String(), "claimed") ||
+		strings.Contains(record.Body.String(), missingPath) {
+		t.Errorf("expected the error to name only the snapshot, got %s", record.Body)
+	}
+}
+
+func TestSearchHandlerPagination(t *testing.T) {
+	idx, err := bleve.NewMemOnly(bleve.NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	RegisterIndexName("pagination", idx)
+	defer func() {
+		UnregisterIndexByName("pagination")
+		err := idx.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+	for i := 0; i < 5; i++ {
+		err = idx.Index(fmt.Sprintf("doc-%d", i), map[string]interface{}{"name": "marty"})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	handler := NewSearchHandler("pagination")
+	record := httptest.NewRecorder()
+	req := &http.Request{
+		Method: "POST",
+		URL:    &url.URL{Path: "/search"},
+		Body:   io.NopCloser(strings.NewReader(`{"query":{"match_all":{}},"from":2,"size":2}`)),
+	}
+	handler.ServeHTTP(record, req)
+	if record.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", record.Code, record.Body)
+	}
+	var rv struct {
+		Pagination *bleve.Pagination `json:"pagination"`
+	}
+	err = json.Unmarshal(record.Body.Bytes(), &rv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &bleve.Pagination{Page: 2, PageSize: 2, TotalPages: 3, HasNext: true, HasPrev: true}
+	if !reflect.DeepEqual(rv.Pagination, expected) {
+		t.Errorf("expected pagination %+v, got %+v", expected, rv.Pagination)
+	}
+}
+
+func TestSearchHandlerPretty(t *testing.T) {
+	idx, err := bleve.NewMemOnly(bleve.NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	RegisterIndexName("pretty", idx)
+	defer func() {
+		UnregisterIndexByName("pretty")
+		err := idx.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+	err = idx.Index("a", map[string]interface{}{"name": "marty"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler := NewSearchHandler("pretty")
+	for _, pretty := range []bool{false, true} {
+		rawQuery := ""
+		if pretty {
+			rawQuery = "pretty=true"
+		}
+		record := httptest.NewRecorder()
+		req := &http.Request{
+			Method: "POST",
+			URL:    &url.URL{Path: "/search", RawQuery: rawQuery},
+			Body:   io.NopCloser(strings.NewReader(`{"query":{"match_all":{}}}`)),
+		}
+		handler.ServeHTTP(record, req)
+		if record.Code != http.StatusOK {
+			t.Fatalf("pretty %t: expected status 200, got %d: %s", pretty, record.Code, record.Body)
+		}
+		body := record.Body.Bytes()
+		if !json.Valid(body) {
+			t.Fatalf("pretty %t: expected valid JSON, got %s", pretty, body)
+		}
+		lines := strings.Count(strings.TrimSpace(string(body)), "\n") + 1
+		if pretty && !strings.Contains(string(body), "\n  \"") {
+			t.Errorf("expected indented JSON, got %s", body)
+		}
+		if !pretty && lines != 1 {
+			t.Errorf("expected compact JSON on a single line, got %d lines", lines)
+		}
+	}
+}
+
+func TestCreateIndexHandlerBackend(t *testing.T) {
+	basePath := "testbackend"
+	err := os.MkdirAll(basePath, 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err := os.RemoveAll(basePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	handler := NewCreateIndexHandler(basePath)
+	handler.IndexNameLookup = indexNameLookup
+	tests := []struct {
+		backend          string
+		defaultIndexType string
+		indexType        string
+		onDisk           bool
+	}{
+		{backend: "", indexType: "scorch", onDisk: true},
+		{backend: "", defaultIndexType: "upside_down", indexType: "upside_down", onDisk: true},
+		{backend: BackendScorch, indexType: "scorch", onDisk: true},
+		{backend: BackendBoltDB, indexType: "upside_down", onDisk: true},
+		{backend: BackendMemory, indexType: "upside_down", onDisk: false},
+	}
+	for i, test := range tests {
+		name := fmt.Sprintf("backend-%d", i)
+		record := httptest.NewRecorder()
+		req := &http.Request{
+			Method: "PUT",
+			URL: &url.URL{Path: "/create", RawQuery: url.Values{
+				"indexName": []string{name},
+				"backend":   []string{test.backend},
+			}.Encode()},
+			Body: io.NopCloser(strings.NewReader("{}")),
+		}
+		if test.defaultIndexType != "" {
+			defaultIndexType := bleve.Config.DefaultIndexType
+			bleve.Config.DefaultIndexType = test.defaultIndexType
+			handler.ServeHTTP(record, req)
+			bleve.Config.DefaultIndexType = defaultIndexType
+		} else {
+			handler.ServeHTTP(record, req)
+		}
+		if record.Code != http.StatusOK {
+			t.Fatalf("backend %q: expected status 200, got %d: %s", test.backend, record.Code, record.Body)
+		}
+
+		idx := IndexByName(name)
+		err = idx.Index("a", map[string]interface{}{"name": "marty"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, err := idx.Search(bleve.NewSearchRequest(bleve.NewMatchQuery("marty")))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Total != 1 {
+			t.Errorf("backend %q: expected 1 hit, got %d", test.backend, res.Total)
+		}
+		advanced, err := idx.Advanced()
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, isScorch := advanced.(*scorch.Scorch)
+		if isScorch != (test.indexType == "scorch") {
+			t.Errorf("backend %q: expected index type %s, got %T", test.backend, test.indexType, advanced)
+		}
+		_, err = os.Stat(basePath + string(os.PathSeparator) + name)
+		if onDisk := err == nil; onDisk != test.onDisk {
+			t.Errorf("backend %q: expected index on disk %t, got %t", test.backend, test.onDisk, onDisk)
+		}
+
+		UnregisterIndexByName(name)
+		err = idx.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	record := httptest.NewRecorder()
+	req := &http.Request{
+		Method: "PUT",
+		URL:    &url.URL{Path: "/create", RawQuery: "indexName=unknown&backend=leveldb"},
+		Body:   io.NopCloser(strings.NewReader("{}")),
+	}
+	handler.ServeHTTP(record, req)
+	if record.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for unknown backend, got %d", record.Code)
+	}
+}
+
+func TestSearchHandlerWarnings(t *testing.T) {
+	im := bleve.NewIndexMapping()
+	bodyMapping := bleve.NewTextFieldMapping()
+	bodyMapping.Store = false
+	im.DefaultMapping.AddFieldMappingsAt("body", bodyMapping)
+	idx, err := bleve.NewMemOnly(im)
+	if err != nil {
+		t.Fatal(err)
+	}
+	RegisterIndexName("warnings", idx)
+	defer func() {
+		UnregisterIndexByName("warnings")
+		err := idx.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+	err = idx.Index("a", map[string]interface{}{
+		"title": "release notes",
+		"body":  "the search engine got faster",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler := NewSearchHandler("warnings")
+	record := httptest.NewRecorder()
+	req := &http.Request{
+		Method: "POST",
+		URL:    &url.URL{Path: "/search"},
+		Body:   io.NopCloser(strings.NewReader(`{"query":{"match":"engine","field":"body"},"fields":["title","body"],"include_warnings":true}`)),
+	}
+	handler.ServeHTTP(record, req)
+	if record.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", record.Code, record.Body)
+	}
+	var res bleve.SearchResult
+	err = json.Unmarshal(record.Body.Bytes(), &res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Hits) != 1 || res.Hits[0].Fields["title"] != "release notes" {
+		t.Errorf("expected the hit with its stored title, got %v", res.Hits)
+	}
+	expected := []string{"field 'body' is not stored, its values cannot be returned"}
+	if !reflect.DeepEqual(res.Warnings, expected) {
+		t.Errorf("expected warnings %v, got %v", expected, res.Warnings)
+	}
+}
+
+func TestSearchHandlerPopularity(t *testing.T) {
+	idx, err := bleve.NewMemOnly(bleve.NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	RegisterIndexName("popularity", idx)
+	defer func() {
+		UnregisterIndexByName("popularity")
+		err := idx.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+	for id, views := range map[string]float64{"a": 10, "b": 1000} {
+		err = idx.Index(id, map[string]interface{}{"name": "marty", "views": views})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	handler := NewSearchHandler("popularity")
+	record := httptest.NewRecorder()
+	req := &http.Request{
+		Method: "POST",
+		URL:    &url.URL{Path: "/search", RawQuery: "popularity=log&popularity_field=views"},
+		Body:   io.NopCloser(strings.NewReader(`{"query":{"match":"marty","field":"name"}}`)),
+	}
+	handler.ServeHTTP(record, req)
+	if record.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", record.Code, record.Body)
+	}
+	var res bleve.SearchResult
+	err = json.Unmarshal(record.Body.Bytes(), &res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Hits) != 2 || res.Hits[0].ID != "b" {
+		t.Fatalf("expected the most viewed document first, got %v", res.Hits)
+	}
+	// the popularity field is not added to the returned fields
+	if res.Hits[0].Fields != nil {
+		t.Errorf("expected no fields returned, got %v", res.Hits[0].Fields)
+	}
+}
+
+func TestSearchHandlerRelevanceThreshold(t *testing.T) {
+	idx, err := bleve.NewMemOnly(bleve.NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	RegisterIndexName("relevance", idx)
+	defer func() {
+		UnregisterIndexByName("relevance")
+		err := idx.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+	for id, name := range map[string]string{"a": "marty marty marty", "b": "marty schoch and many other words"} {
+		err = idx.Index(id, map[string]interface{}{"name": name})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	search := func(rawQuery string) (int, []byte) {
+		record := httptest.NewRecorder()
+		req := &http.Request{
+			Method: "POST",
+			URL:    &url.URL{Path: "/search", RawQuery: rawQuery},
+			Body:   io.NopCloser(strings.NewReader(`{"query":{"match":"marty","field":"name"}}`)),
+		}
+		NewSearchHandler("relevance").ServeHTTP(record, req)
+		return record.Code, record.Body.Bytes()
+	}
+
+	code, body := search("")
+	if code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", code, body)
+	}
+	var res bleve.SearchResult
+	err = json.Unmarshal(body, &res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Hits) != 2 || res.Hits[0].Relevant != nil {
+		t.Fatalf("expected 2 hits without relevant flag, got %v", res.Hits)
+	}
+	threshold := (res.Hits[0].Score + res.Hits[1].Score) / 2
+
+	code, body = search(fmt.Sprintf("relevance_threshold=%g", threshold))
+	if code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", code, body)
+	}
+	res = bleve.SearchResult{}
+	err = json.Unmarshal(body, &res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Hits) != 2 {
+		t.Fatalf("expected both hits returned, got %d", len(res.Hits))
+	}
+	if res.Hits[0].Relevant == nil || !*res.Hits[0].Relevant {
+		t.Errorf("expected the top hit to be relevant")
+	}
+	if res.Hits[1].Relevant == nil || *res.Hits[1].Relevant {
+		t.Errorf("expected the last hit not to be relevant")
+	}
+
+	code, _ = search("relevance_threshold=high")
+	if code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for an invalid threshold, got %d", code)
+	}
+}
+
+func TestDocDeleteHandlersDeleteVersion(t *testing.T) {
+	idx, err := bleve.NewMemOnly(bleve.NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	RegisterIndexName("versiondelete", idx)
+	defer func() {
+		UnregisterIndexByName("versiondelete")
+		err := idx.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	indexHandler := NewDocIndexHandler("versiondelete")
+	indexHandler.DocIDLookup = docIDLookup
+	for _, docID := range []string{"a", "b", "c"} {
+		record := httptest.NewRecorder()
+		req := &http.Request{
+			Method: "PUT",
+			URL:    &url.URL{Path: "/versiondelete/" + docID, RawQuery: "docID=" + docID},
+			Body:   io.NopCloser(strings.NewReader(`{"name":"marty"}`)),
+		}
+		indexHandler.ServeHTTP(record, req)
+		if record.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", record.Code, record.Body)
+		}
+	}
+
+	deleteHandler := NewDocDeleteHandler("versiondelete")
+	deleteHandler.DocIDLookup = docIDLookup
+	record := httptest.NewRecorder()
+	deleteHandler.ServeHTTP(record, &http.Request{
+		Method: "DELETE",
+		URL:    &url.URL{Path: "/versiondelete/a", RawQuery: "docID=a"},
+	})
+	if record.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", record.Code, record.Body)
+	}
+
+	bulkDeleteHandler := NewDocBulkDeleteHandler("versiondelete")
+	record = httptest.NewRecorder()
+	bulkDeleteHandler.ServeHTTP(record, &http.Request{
+		Method: "POST",
+		URL:    &url.URL{Path: "/versiondelete/_bulk_delete"},
+		Body:   io.NopCloser(strings.NewReader(`{"ids":["b"]}`)),
+	})
+	if record.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", record.Code, record.Body)
+	}
+	if !strings.Contains(record.Body.String(), `"deleted":1`) {
+		t.Errorf("expected 1 document deleted, got %s", record.Body)
+	}
+
+	for docID, expected := range map[string]uint64{"a": 0, "b": 0, "c": 1} {
+		version, err := docVersion(idx, docID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if version != expected {
+			t.Errorf("expected document %s at version %d, got %d", docID, expected, version)
+		}
+	}
+	count, err := idx.DocCount()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("expected 1 document left, got %d", count)
+	}
+
+	// a deleted document created again starts over at version 0, a
+	// write expecting its version before the delete is rejected
+	for _, test := range []struct {
+		version string
+		code    int
+		resp    string
+	}{
+		{
+			version: "1",
+			code:    http.StatusConflict,
+			resp:    `{"error":{"message":"version conflict, document 'a' is at version 0, not 1","code":409}}`,
+		},
+		{
+			version: "0",
+			code:    http.StatusOK,
+			resp:    `{"status":"ok","version":1}`,
+		},
+	} {
+		record := httptest.NewRecorder()
+		indexHandler.ServeHTTP(record, &http.Request{
+			Method: "PUT",
+			URL:    &url.URL{Path: "/versiondelete/a", RawQuery: "docID=a&version=" + test.version},
+			Body:   io.NopCloser(strings.NewReader(`{"name":"marty"}`)),
+		})
+		if record.Code != test.code {
+			t.Errorf("version %s: expected status %d, got %d: %s", test.version, test.code, record.Code, record.Body)
+		}
+		if got := strings.TrimSpace(record.Body.String()); got != test.resp {
+			t.Errorf("version %s: expected %s, got %s", test.version, test.resp, got)
+		}
+	}
+
+	// versioned writes to other indexes do not share the lock
+	other, err := bleve.NewMemOnly(bleve.NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		forgetDocVersionLock(other)
+		_ = other.Close()
+	}()
+	if docVersionLock(idx) == docVersionLock(other) {
+		t.Errorf("expected distinct version locks per index")
+	}
+}
@@ -22,6 +22,19 @@ import (
 	"os"
 
 	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/index/scorch"
+	"github.com/blevesearch/bleve/v2/index/upsidedown"
+	"github.com/blevesearch/bleve/v2/index/upsidedown/store/boltdb"
+)
+
+const (
+	// BackendScorch stores the index on disk using scorch.
+	BackendScorch = "scorch"
+	// BackendBoltDB stores the index on disk using upside_down on boltdb.
+	BackendBoltDB = "boltdb"
+	// BackendMemory keeps the index in memory only, it is lost once
+	// the index is closed.
+	BackendMemory = "memory"
 )
 
 type CreateIndexHandler struct {
@@ -64,7 +77,24 @@ func (h *CreateIndexHandler) ServeHTTP(w http.ResponseWriter, req *http.Request)
 		}
 	}
 
-	newIndex, err := bleve.New(h.indexPath(indexName), indexMapping)
+	// backend selects how the index is persisted, without it the
+	// index is created with the configured default index type
+	var newIndex bleve.Index
+	switch backend := req.FormValue("backend"); backend {
+	case "":
+		newIndex, err = bleve.New(h.indexPath(indexName), indexMapping)
+	case BackendScorch:
+		newIndex, err = bleve.NewUsing(h.indexPath(indexName), indexMapping,
+			scorch.Name, bleve.Config.DefaultKVStore, nil)
+	case BackendBoltDB:
+		newIndex, err = bleve.NewUsing(h.indexPath(indexName), indexMapping,
+			upsidedown.Name, boltdb.Name, nil)
+	case BackendMemory:
+		newIndex, err = bleve.NewMemOnly(indexMapping)
+	default:
+		showError(w, req, fmt.Sprintf("unknown backend '%s'", backend), 400)
+		return
+	}
 	if err != nil {
 		showError(w, req, fmt.Sprintf("error creating index: %v", err), 500)
 		return
@@ -0,0 +1,78 @@
+//  Copyright (c) 2024 Couchbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 		http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// IndexInfoHandler reports how fresh an index is: its document
+// count, the generation of its current snapshot when the index
+// exposes one, and the last time the document handlers of this
+// server wrote to it.
+type IndexInfoHandler struct {
+	defaultIndexName string
+	IndexNameLookup  varLookupFunc
+}
+
+func NewIndexInfoHandler(defaultIndexName string) *IndexInfoHandler {
+	return &IndexInfoHandler{
+		defaultIndexName: defaultIndexName,
+	}
+}
+
+func (h *IndexInfoHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	// find the index to operate on
+	var indexName string
+	if h.IndexNameLookup != nil {
+		indexName = h.IndexNameLookup(req)
+	}
+	if indexName == "" {
+		indexName = h.defaultIndexName
+	}
+	index := IndexByName(indexName)
+	if index == nil {
+		showError(w, req, fmt.Sprintf("no such index '%s'", indexName), 404)
+		return
+	}
+
+	docCount, err := index.DocCount()
+	if err != nil {
+		showError(w, req, fmt.Sprintf("error counting docs: %v", err), 500)
+		return
+	}
+
+	rv := struct {
+		Status     string     `json:"status"`
+		DocCount   uint64     `json:"doc_count"`
+		Generation *uint64    `json:"generation,omitempty"`
+		LastWrite  *time.Time `json:"last_write,omitempty"`
+	}{
+		Status:   "ok",
+		DocCount: docCount,
+	}
+	// scorch numbers each snapshot it introduces with an epoch
+	if advanced, err := index.Advanced(); err == nil {
+		if epoch, ok := advanced.StatsMap()["CurRootEpoch"].(uint64); ok {
+			rv.Generation = &epoch
+		}
+	}
+	if lastWrite := IndexLastWrite(indexName); !lastWrite.IsZero() {
+		rv.LastWrite = &lastWrite
+	}
+	mustEncode(w, rv)
+}
@@ -0,0 +1,117 @@
+//  Copyright (c) 2024 Couchbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 		http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/blevesearch/bleve/v2/index/scorch"
+	"github.com/blevesearch/bleve/v2/index/scorch/mergeplan"
+	index "github.com/blevesearch/bleve_index_api"
+)
+
+// forceMerger is implemented by index implementations
+// which support merging their segments on demand
+type forceMerger interface {
+	ForceMerge(ctx context.Context, mo *mergeplan.MergePlanOptions) error
+}
+
+type segmentStats struct {
+	Segments uint64 `json:"segments"`
+	Bytes    uint64 `json:"bytes"`
+}
+
+// OptimizeIndexHandler merges the segments of an index
+// into as few segments as possible.  The index remains
+// available for searching while the merge is underway,
+// optimizing it again meanwhile is rejected with 409.
+type OptimizeIndexHandler struct {
+	defaultIndexName string
+	IndexNameLookup  varLookupFunc
+}
+
+func NewOptimizeIndexHandler(defaultIndexName string) *OptimizeIndexHandler {
+	return &OptimizeIndexHandler{
+		defaultIndexName: defaultIndexName,
+	}
+}
+
+func (h *OptimizeIndexHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	// find the index to operate on
+	var indexName string
+	if h.IndexNameLookup != nil {
+		indexName = h.IndexNameLookup(req)
+	}
+	if indexName == "" {
+		indexName = h.defaultIndexName
+	}
+	idx := IndexByName(indexName)
+	if idx == nil {
+		showError(w, req, fmt.Sprintf("no such index '%s'", indexName), 404)
+		return
+	}
+
+	advanced, err := idx.Advanced()
+	if err != nil {
+		showError(w, req, fmt.Sprintf("error optimizing index: %v", err), 500)
+		return
+	}
+	merger, ok := advanced.(forceMerger)
+	if !ok {
+		showError(w, req, fmt.Sprintf("index '%s' does not support optimizing", indexName), 400)
+		return
+	}
+
+	before := indexSegmentStats(advanced)
+	err = merger.ForceMerge(req.Context(), nil)
+	if errors.Is(err, scorch.ErrForceMergeInProgress) {
+		showError(w, req, fmt.Sprintf("index '%s' is already being optimized", indexName), 409)
+		return
+	}
+	if err != nil {
+		showError(w, req, fmt.Sprintf("error optimizing index: %v", err), 500)
+		return
+	}
+	after := indexSegmentStats(advanced)
+
+	rv := struct {
+		Status string       `json:"status"`
+		Before segmentStats `json:"before"`
+		After  segmentStats `json:"after"`
+	}{
+		Status: "ok",
+		Before: before,
+		After:  after,
+	}
+	mustEncode(w, rv)
+}
+
+func indexSegmentStats(i index.Index) segmentStats {
+	var rv segmentStats
+	stats := i.StatsMap()
+	if v, ok := stats["num_root_memorysegments"].(uint64); ok {
+		rv.Segments += v
+	}
+	if v, ok := stats["num_root_filesegments"].(uint64); ok {
+		rv.Segments += v
+	}
+	if v, ok := stats["num_bytes_used_disk_by_root"].(uint64); ok {
+		rv.Bytes = v
+	}
+	return rv
+}
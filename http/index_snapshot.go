@@ -0,0 +1,141 @@
+//  Copyright (c) 2024 Couchbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 		http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/blevesearch/bleve/v2"
+)
+
+// SnapshotIndexHandler copies an index, as of the time of the
+// request, into a new directory under basePath.  The index keeps
+// serving searches and accepting writes during the copy, writes
+// made after the request started are not part of the snapshot.
+// The snapshot is a regular index which can be opened on its own.
+type SnapshotIndexHandler struct {
+	basePath         string
+	defaultIndexName string
+	IndexNameLookup  varLookupFunc
+	// SnapshotNameLookup names the directory of the snapshot,
+	// by default it is the index name followed by a timestamp.
+	SnapshotNameLookup varLookupFunc
+}
+
+func NewSnapshotIndexHandler(basePath, defaultIndexName string) *SnapshotIndexHandler {
+	return &SnapshotIndexHandler{
+		basePath:         basePath,
+		defaultIndexName: defaultIndexName,
+	}
+}
+
+func (h *SnapshotIndexHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	// find the index to operate on
+	var indexName string
+	if h.IndexNameLookup != nil {
+		indexName = h.IndexNameLookup(req)
+	}
+	if indexName == "" {
+		indexName = h.defaultIndexName
+	}
+	idx := IndexByName(indexName)
+	if idx == nil {
+		showError(w, req, fmt.Sprintf("no such index '%s'", indexName), 404)
+		return
+	}
+	copyable, ok := idx.(bleve.IndexCopyable)
+	if !ok {
+		showError(w, req, fmt.Sprintf("index '%s' does not support snapshots", indexName), 400)
+		return
+	}
+
+	// find the name of the snapshot
+	var snapshotName string
+	if h.SnapshotNameLookup != nil {
+		snapshotName = h.SnapshotNameLookup(req)
+	}
+	if snapshotName == "" {
+		snapshotName = indexName + "-" + time.Now().UTC().Format("20060102T150405.000000000")
+	}
+	if strings.ContainsAny(snapshotName, `/\`) || snapshotName == "." || snapshotName == ".." {
+		showError(w, req, fmt.Sprintf("invalid snapshot name '%s'", snapshotName), 400)
+		return
+	}
+	snapshotPath := h.basePath + string(os.PathSeparator) + snapshotName
+
+	// creating the directory claims the snapshot name, so concurrent
+	// requests for the same name cannot copy into the same directory
+	err := os.Mkdir(snapshotPath, 0700)
+	if errors.Is(err, fs.ErrExist) {
+		showError(w, req, fmt.Sprintf("snapshot '%s' already exists", snapshotName), 409)
+		return
+	}
+	if err != nil {
+		showError(w, req, fmt.Sprintf("error creating snapshot '%s': %s", snapshotName,
+			snapshotErrorMessage(err, snapshotPath, snapshotName)), 500)
+		return
+	}
+
+	err = copyable.CopyTo(bleve.FileSystemDirectory(snapshotPath))
+	if err != nil {
+		_ = os.RemoveAll(snapshotPath)
+		showError(w, req, fmt.Sprintf("error copying index: %s",
+			snapshotErrorMessage(err, snapshotPath, snapshotName)), 500)
+		return
+	}
+
+	// open the snapshot to make sure it is usable
+	snapshot, err := bleve.Open(snapshotPath)
+	if err != nil {
+		_ = os.RemoveAll(snapshotPath)
+		showError(w, req, fmt.Sprintf("error opening snapshot: %s",
+			snapshotErrorMessage(err, snapshotPath, snapshotName)), 500)
+		return
+	}
+	docCount, err := snapshot.DocCount()
+	if cerr := snapshot.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		_ = os.RemoveAll(snapshotPath)
+		showError(w, req, fmt.Sprintf("error counting snapshot docs: %s",
+			snapshotErrorMessage(err, snapshotPath, snapshotName)), 500)
+		return
+	}
+
+	rv := struct {
+		Status   string `json:"status"`
+		Path     string `json:"path"`
+		DocCount uint64 `json:"doc_count"`
+	}{
+		Status:   "ok",
+		Path:     snapshotPath,
+		DocCount: docCount,
+	}
+	mustEncode(w, rv)
+}
+
+// snapshotErrorMessage returns the message of err with the path of
+// the snapshot replaced by its name, keeping the layout of the server
+// out of the responses.
+func snapshotErrorMessage(err error, snapshotPath, snapshotName string) string {
+	return strings.ReplaceAll(err.Error(), snapshotPath, snapshotName)
+}
@@ -0,0 +1,63 @@
+//  Copyright (c) 2024 Couchbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 		http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/blevesearch/bleve/v2"
+)
+
+// ValidateMappingHandler checks the index mapping in the
+// request body and reports all the problems found in it,
+// without creating an index.
+type ValidateMappingHandler struct{}
+
+func NewValidateMappingHandler() *ValidateMappingHandler {
+	return &ValidateMappingHandler{}
+}
+
+func (h *ValidateMappingHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	// read the request body
+	requestBody, err := io.ReadAll(req.Body)
+	if err != nil {
+		showError(w, req, fmt.Sprintf("error reading request body: %v", err), 400)
+		return
+	}
+	if len(requestBody) == 0 {
+		showError(w, req, "index mapping is required", 400)
+		return
+	}
+
+	indexMapping := bleve.NewIndexMapping()
+	err = json.Unmarshal(requestBody, &indexMapping)
+	if err != nil {
+		showError(w, req, fmt.Sprintf("error parsing index mapping: %v", err), 400)
+		return
+	}
+
+	rv := struct {
+		Valid  bool     `json:"valid"`
+		Errors []string `json:"errors,omitempty"`
+	}{}
+	for _, err := range indexMapping.ValidationErrors() {
+		rv.Errors = append(rv.Errors, err.Error())
+	}
+	rv.Valid = len(rv.Errors) == 0
+	mustEncode(w, rv)
+}
@@ -17,6 +17,7 @@ package http
 import (
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/blevesearch/bleve/v2"
 )
@@ -24,6 +25,9 @@ import (
 var indexNameMapping map[string]bleve.Index
 var indexNameMappingLock sync.RWMutex
 
+var indexLastWrite map[string]time.Time
+var indexLastWriteLock sync.RWMutex
+
 func RegisterIndexName(name string, idx bleve.Index) {
 	indexNameMappingLock.Lock()
 	defer indexNameMappingLock.Unlock()
@@ -44,10 +48,37 @@ func UnregisterIndexByName(name string) bleve.Index {
 	rv := indexNameMapping[name]
 	if rv != nil {
 		delete(indexNameMapping, name)
+		forgetDocVersionLock(rv)
 	}
+
+	indexLastWriteLock.Lock()
+	delete(indexLastWrite, name)
+	indexLastWriteLock.Unlock()
+
 	return rv
 }
 
+// markIndexWritten records that a handler has just written
+// to the named index.
+func markIndexWritten(name string) {
+	indexLastWriteLock.Lock()
+	defer indexLastWriteLock.Unlock()
+
+	if indexLastWrite == nil {
+		indexLastWrite = make(map[string]time.Time)
+	}
+	indexLastWrite[name] = time.Now()
+}
+
+// IndexLastWrite returns the time the handlers last wrote to
+// the named index, or the zero time if they never did.
+func IndexLastWrite(name string) time.Time {
+	indexLastWriteLock.RLock()
+	defer indexLastWriteLock.RUnlock()
+
+	return indexLastWrite[name]
+}
+
 func IndexByName(name string) bleve.Index {
 	indexNameMappingLock.RLock()
 	defer indexNameMappingLock.RUnlock()
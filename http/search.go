@@ -17,24 +17,66 @@ package http
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/blevesearch/bleve/v2"
 	"github.com/blevesearch/bleve/v2/search/query"
 )
 
+// DefaultMaxResultWindow is the default limit on From+Size
+// for requests handled by a SearchHandler
+const DefaultMaxResultWindow = 10000
+
+// DefaultMaxClauseCount is the default limit on the number of
+// clauses of the queries handled by a SearchHandler
+const DefaultMaxClauseCount = 1024
+
+// StatusClientClosedRequest is the non-standard status reported
+// when the client goes away before the search completes
+const StatusClientClosedRequest = 499
+
 // SearchHandler can handle search requests sent over HTTP
 type SearchHandler struct {
 	defaultIndexName string
 	IndexNameLookup  varLookupFunc
+
+	// MaxResultWindow bounds From+Size of a request, deep
+	// pagination should use search_after instead.
+	// A value of 0 or less disables the check.
+	MaxResultWindow int
+
+	// Timeout bounds the duration of searches which do not
+	// specify their own timeout parameter.
+	// A value of 0 or less lets them run until completion.
+	Timeout time.Duration
+
+	// AllowLeadingWildcard accepts wildcard queries starting with
+	// a wildcard and regexp queries without a literal prefix, which
+	// scan the whole term dictionary.  They are otherwise rejected
+	// unless the request sets allow_leading_wildcard=true.
+	AllowLeadingWildcard bool
+
+	// MaxClauseCount rejects requests whose query and filter have
+	// more clauses, leaf queries, in total.  A value of 0 or less
+	// accepts queries of any size.
+	MaxClauseCount int
+
+	// DefaultFields are the stored fields returned with the hits
+	// of requests which do not list their own fields.  A request
+	// listing no fields, "fields": [], gets none.
+	DefaultFields []string
 }
 
 func NewSearchHandler(defaultIndexName string) *SearchHandler {
 	return &SearchHandler{
 		defaultIndexName: defaultIndexName,
+		MaxResultWindow:  DefaultMaxResultWindow,
+		MaxClauseCount:   DefaultMaxClauseCount,
 	}
 }
 
@@ -73,7 +115,15 @@ func (h *SearchHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 
 	logger.Printf("parsed request %#v", searchRequest)
 
+	if searchRequest.Fields == nil && len(h.DefaultFields) > 0 {
+		searchRequest.Fields = append([]string(nil), h.DefaultFields...)
+	}
+
 	// validate the query
+	if searchRequest.Query == nil && searchRequest.Filter == nil {
+		showError(w, req, "error validating query: search request must have a query or a filter", 400)
+		return
+	}
 	if srqv, ok := searchRequest.Query.(query.ValidatableQuery); ok {
 		err = srqv.Validate()
 		if err != nil {
@@ -81,28 +131,280 @@ func (h *SearchHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 			return
 		}
 	}
+	if srqv, ok := searchRequest.Filter.(query.ValidatableQuery); ok {
+		err = srqv.Validate()
+		if err != nil {
+			showError(w, req, fmt.Sprintf("error validating filter: %v", err), 400)
+			return
+		}
+	}
+
+	allowLeadingWildcard := h.AllowLeadingWildcard ||
+		req.FormValue("allow_leading_wildcard") == "true"
+	if !allowLeadingWildcard {
+		for _, q := range searchRequest.Queries() {
+			if err := query.CheckLeadingWildcards(q); err != nil {
+				showError(w, req, fmt.Sprintf("%v, set allow_leading_wildcard=true "+
+					"to run it anyway", err), 400)
+				return
+			}
+		}
+	}
+
+	if h.MaxClauseCount > 0 {
+		clauses := 0
+		for _, q := range searchRequest.Queries() {
+			clauses += query.CountClauses(q)
+		}
+		if clauses > h.MaxClauseCount {
+			showError(w, req, fmt.Sprintf("query has too many clauses, it has %d "+
+				"but at most %d are allowed", clauses, h.MaxClauseCount), 400)
+			return
+		}
+	}
+
+	if h.MaxResultWindow > 0 &&
+		searchRequest.From+searchRequest.Size > h.MaxResultWindow {
+		showError(w, req, fmt.Sprintf("result window is too large, from + size must be "+
+			"less than or equal to %d but was %d, use search_after to page deeper",
+			h.MaxResultWindow, searchRequest.From+searchRequest.Size), 400)
+		return
+	}
+
+	// explain_format=tree adds the explanations of the
+	// hits rendered as indented text trees to the response
+	var explainTree bool
+	switch explainFormat := req.FormValue("explain_format"); explainFormat {
+	case "":
+	case "tree":
+		explainTree = true
+		searchRequest.Explain = true
+	default:
+		showError(w, req, fmt.Sprintf("unknown explain format '%s'", explainFormat), 400)
+		return
+	}
+
+	// no_score=true skips scoring the hits
+	if req.FormValue("no_score") == "true" {
+		searchRequest.Score = bleve.ScoreNone
+	}
+
+	// freshness=f blends the recency of the date in freshness_field
+	// into the ranking of the hits, see SearchResult.RerankByFreshness
+	var freshness float64
+	freshnessField := req.FormValue("freshness_field")
+	if freshnessStr := req.FormValue("freshness"); freshnessStr != "" {
+		freshness, err = strconv.ParseFloat(freshnessStr, 64)
+		if err != nil {
+			showError(w, req, fmt.Sprintf("error parsing freshness value: %v", err), 400)
+			return
+		}
+		if freshness < 0 || freshness > 1 {
+			showError(w, req, fmt.Sprintf("freshness must be between 0 and 1, got %g", freshness), 400)
+			return
+		}
+		if freshnessField == "" {
+			showError(w, req, "freshness requires a freshness_field", 400)
+			return
+		}
+		if !fieldRequested(searchRequest.Fields, freshnessField) {
+			searchRequest.Fields = append(searchRequest.Fields, freshnessField)
+		}
+	}
+
+	// popularity=modifier multiplies the scores of the hits by a
+	// function of the value of popularity_field, scaled by
+	// popularity_factor, see SearchResult.BoostByField; only the hits
+	// of the requested page are reordered, not the hits across pages
+	popularity := req.FormValue("popularity")
+	popularityField := req.FormValue("popularity_field")
+	popularityFactor := 1.0
+	if popularity != "" {
+		switch popularity {
+		case bleve.BoostModifierLinear, bleve.BoostModifierLog, bleve.BoostModifierSqrt:
+		default:
+			showError(w, req, fmt.Sprintf("unknown popularity modifier '%s'", popularity), 400)
+			return
+		}
+		if factorStr := req.FormValue("popularity_factor"); factorStr != "" {
+			popularityFactor, err = strconv.ParseFloat(factorStr, 64)
+			if err != nil {
+				showError(w, req, fmt.Sprintf("error parsing popularity_factor value: %v", err), 400)
+				return
+			}
+			if popularityFactor <= 0 {
+				showError(w, req, fmt.Sprintf("popularity_factor must be positive, got %g", popularityFactor), 400)
+				return
+			}
+		}
+		if popularityField == "" {
+			showError(w, req, "popularity requires a popularity_field", 400)
+			return
+		}
+	}
+
+	// relevance_threshold=t flags each hit as relevant or not by
+	// comparing its final score to t, see SearchResult.MarkRelevant
+	var relevanceThreshold float64
+	relevanceThresholdStr := req.FormValue("relevance_threshold")
+	if relevanceThresholdStr != "" {
+		relevanceThreshold, err = strconv.ParseFloat(relevanceThresholdStr, 64)
+		if err != nil {
+			showError(w, req, fmt.Sprintf("error parsing relevance_threshold value: %v", err), 400)
+			return
+		}
+	}
+
+	// checksums=true adds a checksum of the returned fields to each
+	// hit, all the stored fields are returned unless some are requested
+	checksums := req.FormValue("checksums") == "true"
+	if checksums && len(searchRequest.Fields) == 0 {
+		searchRequest.Fields = []string{"*"}
+	}
+
+	// format=geojson renders the hits as a GeoJSON feature
+	// collection, geo_field names the field holding the geometry
+	var geoJSON bool
+	geoField := req.FormValue("geo_field")
+	switch format := req.FormValue("format"); format {
+	case "", "json":
+	case "geojson":
+		geoJSON = true
+		if geoField != "" && !fieldRequested(searchRequest.Fields, geoField) {
+			searchRequest.Fields = append(searchRequest.Fields, geoField)
+		}
+	default:
+		showError(w, req, fmt.Sprintf("unknown format '%s'", format), 400)
+		return
+	}
 
-	// check for timeout and create context
-	var ctx context.Context
+	// check for timeout and create context, the search
+	// is also aborted when the client goes away
+	timeout := h.Timeout
 	timeoutStr := req.FormValue("timeout")
-	if timeoutStr == "" {
-		ctx = context.Background()
-	} else {
-		timeout, err := time.ParseDuration(timeoutStr)
+	if timeoutStr != "" {
+		timeout, err = time.ParseDuration(timeoutStr)
 		if err != nil {
 			showError(w, req, fmt.Sprintf("error parsing timeout value: %v", err), 400)
 			return
 		}
-		ctx, _ = context.WithTimeout(context.Background(), timeout)
+	}
+	ctx := req.Context()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
 	}
 
 	// execute the query
 	searchResponse, err := index.SearchInContext(ctx, &searchRequest)
 	if err != nil {
-		showError(w, req, fmt.Sprintf("error executing query: %v", err), 500)
+		switch {
+		case errors.Is(err, context.DeadlineExceeded):
+			showError(w, req, fmt.Sprintf("search timed out: %v", err), http.StatusGatewayTimeout)
+		case errors.Is(err, context.Canceled):
+			showError(w, req, fmt.Sprintf("search canceled: %v", err), StatusClientClosedRequest)
+		default:
+			showError(w, req, fmt.Sprintf("error executing query: %v", err), 500)
+		}
 		return
 	}
 
+	if req.FormValue("normalize_scores") == "true" {
+		searchResponse.NormalizeScores()
+	}
+
+	if popularity != "" {
+		err = searchResponse.BoostByField(index, popularityField, popularity, popularityFactor)
+		if err != nil {
+			showError(w, req, fmt.Sprintf("error boosting by popularity: %v", err), 400)
+			return
+		}
+	}
+
+	if relevanceThresholdStr != "" {
+		searchResponse.MarkRelevant(relevanceThreshold)
+	}
+
+	if freshness > 0 {
+		err = searchResponse.RerankByFreshness(freshnessField, freshness)
+		if err != nil {
+			showError(w, req, fmt.Sprintf("error reranking by freshness: %v", err), 400)
+			return
+		}
+	}
+
+	if checksums {
+		err = searchResponse.ComputeChecksums()
+		if err != nil {
+			showError(w, req, fmt.Sprintf("error computing checksums: %v", err), 500)
+			return
+		}
+	}
+
+	if geoJSON {
+		mustEncodeForRequest(w, req, geoJSONFeatures(searchResponse.Hits, geoField))
+		return
+	}
+
+	rv := struct {
+		*bleve.SearchResult
+		ExplanationTrees map[string]string             `json:"explanation_trees,omitempty"`
+		Diagnosis        []*bleve.ClauseDiagnosis      `json:"diagnosis,omitempty"`
+		Pagination       *bleve.Pagination             `json:"pagination,omitempty"`
+		Profile          *bleve.QueryProfile           `json:"profile,omitempty"`
+		Aggregations     map[string]*bleve.Aggregation `json:"aggregations,omitempty"`
+	}{
+		SearchResult: searchResponse,
+		Pagination:   searchResponse.Pagination(&searchRequest),
+	}
+
+	// render the explanations as text trees if requested
+	if explainTree {
+		rv.ExplanationTrees = make(map[string]string, len(searchResponse.Hits))
+		for _, hit := range searchResponse.Hits {
+			if hit.Expl != nil {
+				rv.ExplanationTrees[hit.ID] = hit.Expl.Tree()
+			}
+		}
+	}
+
+	// aggregations=true also presents the facets with the
+	// same shape whatever their kind
+	if req.FormValue("aggregations") == "true" {
+		rv.Aggregations = searchResponse.Aggregations()
+	}
+
+	// diagnose=true reports the number of documents matched by each
+	// clause of the query and of the filter on its own, when the
+	// search finds no document
+	if req.FormValue("diagnose") == "true" && searchResponse.Total == 0 {
+		rv.Diagnosis, err = bleve.DiagnoseQuery(ctx, index, &searchRequest)
+		if err != nil {
+			showError(w, req, fmt.Sprintf("error diagnosing query: %v", err), 500)
+			return
+		}
+	}
+
+	// profile=true reports the time taken by the query and by
+	// each of its clauses searched on its own
+	if req.FormValue("profile") == "true" {
+		rv.Profile, err = bleve.ProfileQuery(ctx, index, &searchRequest)
+		if err != nil {
+			showError(w, req, fmt.Sprintf("error profiling query: %v", err), 500)
+			return
+		}
+	}
+
 	// encode the response
-	mustEncode(w, searchResponse)
+	mustEncodeForRequest(w, req, rv)
+}
+
+func fieldRequested(fields []string, field string) bool {
+	for _, f := range fields {
+		if f == field || f == "*" {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,116 @@
+//  Copyright (c) 2024 Couchbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 		http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/blevesearch/bleve/v2/search"
+)
+
+type geoJSONPoint struct {
+	Type        string    `json:"type"`
+	Coordinates []float64 `json:"coordinates"`
+}
+
+type geoJSONFeature struct {
+	Type       string                 `json:"type"`
+	ID         string                 `json:"id"`
+	Geometry   interface{}            `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+type geoJSONFeatureCollection struct {
+	Type     string            `json:"type"`
+	Features []*geoJSONFeature `json:"features"`
+}
+
+// geoJSONFeatures renders the hits as a GeoJSON feature collection,
+// using the stored geoField as the geometry of each feature and the
+// other stored fields as its properties.  Without a geoField, the
+// first field holding a geo point is used.  Hits with no geometry
+// are left out.
+func geoJSONFeatures(hits search.DocumentMatchCollection, geoField string) *geoJSONFeatureCollection {
+	rv := &geoJSONFeatureCollection{
+		Type:     "FeatureCollection",
+		Features: make([]*geoJSONFeature, 0, len(hits)),
+	}
+	for _, hit := range hits {
+		field := geoField
+		if field == "" {
+			field = firstGeoPointField(hit.Fields)
+		}
+		geometry := geoJSONGeometry(hit.Fields[field], geoField != "")
+		if geometry == nil {
+			continue
+		}
+		properties := make(map[string]interface{}, len(hit.Fields))
+		for name, value := range hit.Fields {
+			// leave out the geometry, including the parts of
+			// it which were also mapped as separate fields
+			if name != field && !strings.HasPrefix(name, field+".") {
+				properties[name] = value
+			}
+		}
+		rv.Features = append(rv.Features, &geoJSONFeature{
+			Type:       "Feature",
+			ID:         hit.ID,
+			Geometry:   geometry,
+			Properties: properties,
+		})
+	}
+	return rv
+}
+
+// geoJSONGeometry returns the geometry of a stored geo field value,
+// geo points are returned as [lon, lat] and become GeoJSON points,
+// geo shapes are already GeoJSON and are only used when allowed.
+func geoJSONGeometry(value interface{}, allowShapes bool) interface{} {
+	switch value := value.(type) {
+	case nil:
+		return nil
+	case []float64:
+		if len(value) != 2 {
+			return nil
+		}
+		return &geoJSONPoint{Type: "Point", Coordinates: value}
+	case []interface{}:
+		// a field with multiple values, use the first one
+		if len(value) == 0 {
+			return nil
+		}
+		return geoJSONGeometry(value[0], allowShapes)
+	default:
+		if allowShapes {
+			return value
+		}
+		return nil
+	}
+}
+
+func firstGeoPointField(fields map[string]interface{}) string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if geoJSONGeometry(fields[name], false) != nil {
+			return name
+		}
+	}
+	return ""
+}
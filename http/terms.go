@@ -0,0 +1,138 @@
+//  Copyright (c) 2024 Couchbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 		http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+
+	index "github.com/blevesearch/bleve_index_api"
+)
+
+// DefaultTermsTop is the number of terms returned by
+// a TermsHandler when the request does not specify it.
+var DefaultTermsTop = 10
+
+// DefaultTermsMaxScanned bounds the number of dictionary
+// entries a TermsHandler reads for a single request.
+var DefaultTermsMaxScanned = 100000
+
+type termCount struct {
+	Term  string `json:"term"`
+	Count uint64 `json:"count"`
+}
+
+// TermsHandler returns the terms of a field with the highest
+// document frequencies, optionally restricted to a prefix.
+// At most MaxScanned dictionary entries are read, when the
+// dictionary holds more the response is marked as truncated.
+type TermsHandler struct {
+	defaultIndexName string
+	IndexNameLookup  varLookupFunc
+	MaxScanned       int
+}
+
+func NewTermsHandler(defaultIndexName string) *TermsHandler {
+	return &TermsHandler{
+		defaultIndexName: defaultIndexName,
+		MaxScanned:       DefaultTermsMaxScanned,
+	}
+}
+
+func (h *TermsHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	// find the index to operate on
+	var indexName string
+	if h.IndexNameLookup != nil {
+		indexName = h.IndexNameLookup(req)
+	}
+	if indexName == "" {
+		indexName = h.defaultIndexName
+	}
+	idx := IndexByName(indexName)
+	if idx == nil {
+		showError(w, req, fmt.Sprintf("no such index '%s'", indexName), 404)
+		return
+	}
+
+	field := req.FormValue("field")
+	if field == "" {
+		showError(w, req, "field is required", 400)
+		return
+	}
+	top := DefaultTermsTop
+	if topStr := req.FormValue("top"); topStr != "" {
+		var err error
+		top, err = strconv.Atoi(topStr)
+		if err != nil || top < 1 {
+			showError(w, req, fmt.Sprintf("invalid top value: '%s'", topStr), 400)
+			return
+		}
+	}
+	prefix := req.FormValue("prefix")
+
+	var dict index.FieldDict
+	var err error
+	if prefix != "" {
+		dict, err = idx.FieldDictPrefix(field, []byte(prefix))
+	} else {
+		dict, err = idx.FieldDict(field)
+	}
+	if err != nil {
+		showError(w, req, fmt.Sprintf("error reading field dictionary: %v", err), 500)
+		return
+	}
+	defer func() {
+		_ = dict.Close()
+	}()
+
+	terms := make([]termCount, 0)
+	var truncated bool
+	entry, err := dict.Next()
+	for err == nil && entry != nil {
+		if h.MaxScanned > 0 && len(terms) >= h.MaxScanned {
+			truncated = true
+			break
+		}
+		terms = append(terms, termCount{Term: entry.Term, Count: entry.Count})
+		entry, err = dict.Next()
+	}
+	if err != nil {
+		showError(w, req, fmt.Sprintf("error reading field dictionary: %v", err), 500)
+		return
+	}
+
+	sort.Slice(terms, func(i, j int) bool {
+		if terms[i].Count != terms[j].Count {
+			return terms[i].Count > terms[j].Count
+		}
+		return terms[i].Term < terms[j].Term
+	})
+	if len(terms) > top {
+		terms = terms[:top]
+	}
+
+	rv := struct {
+		Field     string      `json:"field"`
+		Terms     []termCount `json:"terms"`
+		Truncated bool        `json:"truncated,omitempty"`
+	}{
+		Field:     field,
+		Terms:     terms,
+		Truncated: truncated,
+	}
+	mustEncode(w, rv)
+}
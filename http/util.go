@@ -21,10 +21,33 @@ import (
 	"net/http"
 )
 
+// errorResponse is the JSON body written by showError, so that
+// clients can handle failures programmatically
+type errorResponse struct {
+	Error errorDetail `json:"error"`
+}
+
+type errorDetail struct {
+	Message string `json:"message"`
+	Code    int    `json:"code"`
+}
+
 func showError(w http.ResponseWriter, r *http.Request,
 	msg string, code int) {
 	logger.Printf("Reporting error %v/%v", code, msg)
-	http.Error(w, msg, code)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Content-type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(code)
+	rv := errorResponse{
+		Error: errorDetail{
+			Message: msg,
+			Code:    code,
+		},
+	}
+	if err := json.NewEncoder(w).Encode(rv); err != nil {
+		logger.Printf("error encoding error response: %v", err)
+	}
 }
 
 func mustEncode(w io.Writer, i interface{}) {
@@ -39,6 +62,26 @@ func mustEncode(w io.Writer, i interface{}) {
 	}
 }
 
+// mustEncodeForRequest is like mustEncode, but indents the
+// JSON when the request asks for it with pretty=true.
+func mustEncodeForRequest(w io.Writer, req *http.Request, i interface{}) {
+	if req.FormValue("pretty") != "true" {
+		mustEncode(w, i)
+		return
+	}
+
+	if headered, ok := w.(http.ResponseWriter); ok {
+		headered.Header().Set("Cache-Control", "no-cache")
+		headered.Header().Set("Content-type", "application/json")
+	}
+
+	e := json.NewEncoder(w)
+	e.SetIndent("", "  ")
+	if err := e.Encode(i); err != nil {
+		panic(err)
+	}
+}
+
 type varLookupFunc func(req *http.Request) string
 
 var logger = log.New(io.Discard, "bleve.http", log.LstdFlags)
@@ -16,6 +16,7 @@ package bleve
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/blevesearch/bleve/v2/index/upsidedown"
 
@@ -290,6 +291,37 @@ func Open(path string) (Index, error) {
 	return openIndexUsing(path, nil)
 }
 
+// OpenOrCreate opens the index at the specified path, or creates
+// it with the provided mapping if the path does not exist or is
+// an empty directory.
+// When an existing index is opened and a mapping is provided,
+// the mapping the index was created with must match it, otherwise
+// the index is closed again and an error wrapping
+// ErrorIndexMappingMismatch, describing the first difference, is
+// returned.  An index which exists but fails to open, for example
+// because it is corrupt, is never recreated, the returned error wraps
+// the reason it failed to open and suggests how to recover.
+func OpenOrCreate(path string, mapping mapping.IndexMapping) (Index, error) {
+	idx, err := openIndexUsing(path, nil)
+	if err == ErrorIndexPathDoesNotExist ||
+		(err == ErrorIndexMetaMissing && isEmptyDir(path)) {
+		return newIndexUsing(path, mapping, Config.DefaultIndexType, Config.DefaultKVStore, nil)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: the index at '%s' exists but could not be "+
+			"opened and was not recreated, restore it from a backup or remove "+
+			"it to create a new empty index", err, path)
+	}
+	if mapping != nil {
+		err = compareIndexMappings(idx.m, mapping)
+		if err != nil {
+			_ = idx.Close()
+			return nil, err
+		}
+	}
+	return idx, nil
+}
+
 // OpenUsing opens index at the specified path, must exist.
 // The mapping used when it was created will be used for all Index/Search operations.
 // The provided runtimeConfig can override settings
@@ -168,7 +168,7 @@ func (s *Scorch) ForceMerge(ctx context.Context,
 	if s.stats.TotFileMergeForceOpsStarted >
 		s.stats.TotFileMergeForceOpsCompleted {
 		s.rootLock.Unlock()
-		return fmt.Errorf("force merge already in progress")
+		return ErrForceMergeInProgress
 	}
 
 	s.stats.TotFileMergeForceOpsStarted++
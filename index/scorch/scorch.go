@@ -36,6 +36,10 @@ const Version uint8 = 2
 
 var ErrClosed = fmt.Errorf("scorch closed")
 
+// ErrForceMergeInProgress is returned by ForceMerge while another
+// force merge of the index is underway.
+var ErrForceMergeInProgress = fmt.Errorf("force merge already in progress")
+
 type Scorch struct {
 	nextSegmentID uint64
 	stats         Stats
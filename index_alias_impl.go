@@ -152,6 +152,10 @@ func (i *indexAliasImpl) Search(req *SearchRequest) (*SearchResult, error) {
 }
 
 func (i *indexAliasImpl) SearchInContext(ctx context.Context, req *SearchRequest) (*SearchResult, error) {
+	if req.Facets.hasSelections() {
+		return searchWithFacetSelections(ctx, i, req)
+	}
+
 	i.mutex.RLock()
 	defer i.mutex.RUnlock()
 
@@ -162,6 +166,10 @@ func (i *indexAliasImpl) SearchInContext(ctx context.Context, req *SearchRequest
 	if len(i.indexes) < 1 {
 		return nil, ErrorAliasEmpty
 	}
+
+	origReq := req
+	req = req.withSortOrder()
+
 	if _, ok := ctx.Value(search.PreSearchKey).(bool); ok {
 		// since preSearchKey is set, it means that the request
 		// is being executed as part of a preSearch, which
@@ -196,7 +204,14 @@ func (i *indexAliasImpl) SearchInContext(ctx context.Context, req *SearchRequest
 		if preSearchData != nil {
 			req.PreSearchData = preSearchData[i.indexes[0].Name()]
 		}
-		return i.indexes[0].SearchInContext(ctx, req)
+		sr, err := i.indexes[0].SearchInContext(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		if req.Explain {
+			sr.Request = origReq
+		}
+		return sr, nil
 	}
 
 	// at this stage we know we have multiple indexes
@@ -247,6 +262,9 @@ func (i *indexAliasImpl) SearchInContext(ctx context.Context, req *SearchRequest
 		}
 	}
 	sr.Took += preSearchDuration
+	if req.Explain {
+		sr.Request = origReq
+	}
 	return sr, nil
 }
 
@@ -777,10 +795,16 @@ func MultiSearch(ctx context.Context, req *SearchRequest, preSearchData map[stri
 
 	sr.Hits = hitsInCurrentPage(req, sr.Hits)
 
+	// matched terms are recomputed for the hits of the page
+	if req.IncludeLocations {
+		sr.MatchedTerms = matchedTerms(sr.Hits)
+	}
+
 	// fix up facets
 	for name, fr := range req.Facets {
 		sr.Facets.Fixup(name, fr.Size)
 	}
+	sortFacetRanges(req, sr.Facets)
 
 	if reverseQueryExecution {
 		// reverse the sort back to the original
@@ -793,6 +817,8 @@ func MultiSearch(ctx context.Context, req *SearchRequest, preSearchData map[stri
 		req.SearchAfter = nil
 	}
 
+	trimFragments(req, sr.Hits)
+
 	// fix up original request
 	if req.Explain {
 		sr.Request = req
@@ -19,7 +19,9 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"sync"
 	"sync/atomic"
@@ -38,6 +40,8 @@ import (
 	"github.com/blevesearch/bleve/v2/search/collector"
 	"github.com/blevesearch/bleve/v2/search/facet"
 	"github.com/blevesearch/bleve/v2/search/highlight"
+	htmlFormatter "github.com/blevesearch/bleve/v2/search/highlight/format/html"
+	simpleHighlighter "github.com/blevesearch/bleve/v2/search/highlight/highlighter/simple"
 	"github.com/blevesearch/bleve/v2/util"
 	index "github.com/blevesearch/bleve_index_api"
 	"github.com/blevesearch/geo/s2"
@@ -461,6 +465,10 @@ func (i *indexImpl) preSearch(ctx context.Context, req *SearchRequest, reader in
 // SearchInContext executes a search request operation within the provided
 // Context. Returns a SearchResult object or an error.
 func (i *indexImpl) SearchInContext(ctx context.Context, req *SearchRequest) (sr *SearchResult, err error) {
+	if req.Facets.hasSelections() {
+		return searchWithFacetSelections(ctx, i, req)
+	}
+
 	i.mutex.RLock()
 	defer i.mutex.RUnlock()
 
@@ -470,6 +478,9 @@ func (i *indexImpl) SearchInContext(ctx context.Context, req *SearchRequest) (sr
 		return nil, ErrorIndexClosed
 	}
 
+	origReq := req
+	req = req.withSortOrder()
+
 	// open a reader for this search
 	indexReader, err := i.i.Reader()
 	if err != nil {
@@ -555,7 +566,18 @@ func (i *indexImpl) SearchInContext(ctx context.Context, req *SearchRequest) (sr
 	ctx = context.WithValue(ctx, search.GeoBufferPoolCallbackKey,
 		search.GeoBufferPoolCallbackFunc(getBufferPool))
 
-	searcher, err := req.Query.Searcher(ctx, indexReader, i.m, search.SearcherOptions{
+	// the searchers report the problems found with their query
+	var queryWarnings []string
+	if req.IncludeWarnings {
+		ctx = context.WithValue(ctx, search.SearchWarningCallbackKey,
+			search.SearchWarningCallbackFunc(func(warning string) {
+				if !containsString(queryWarnings, warning) {
+					queryWarnings = append(queryWarnings, warning)
+				}
+			}))
+	}
+
+	searcher, err := req.scoringQuery().Searcher(ctx, indexReader, i.m, search.SearcherOptions{
 		Explain:            req.Explain,
 		IncludeTermVectors: req.IncludeLocations || req.Highlight != nil,
 		Score:              req.Score,
@@ -580,7 +602,28 @@ func (i *indexImpl) SearchInContext(ctx context.Context, req *SearchRequest) (sr
 	if req.Facets != nil {
 		facetsBuilder := search.NewFacetsBuilder(indexReader)
 		for facetName, facetRequest := range req.Facets {
-			if facetRequest.NumericRanges != nil {
+			if facetRequest.Stats {
+				// build numeric stats facet
+				facetBuilder := facet.NewNumericStatsFacetBuilder(facetRequest.Field)
+				facetsBuilder.Add(facetName, facetBuilder)
+			} else if facetRequest.Interval > 0 {
+				// build numeric histogram facet
+				facetBuilder := facet.NewNumericHistogramFacetBuilder(facetRequest.Field,
+					facetRequest.Interval, facetRequest.Size)
+				facetsBuilder.Add(facetName, facetBuilder)
+			} else if facetRequest.CalendarInterval != "" {
+				// build date histogram facet
+				if !facet.IsCalendarInterval(facetRequest.CalendarInterval) {
+					return nil, fmt.Errorf("unknown calendar interval '%s'", facetRequest.CalendarInterval)
+				}
+				location, err := facetRequest.location()
+				if err != nil {
+					return nil, err
+				}
+				facetBuilder := facet.NewDateTimeHistogramFacetBuilder(facetRequest.Field,
+					facetRequest.CalendarInterval, location)
+				facetsBuilder.Add(facetName, facetBuilder)
+			} else if facetRequest.NumericRanges != nil {
 				// build numeric range facet
 				facetBuilder := facet.NewNumericFacetBuilder(facetRequest.Field, facetRequest.Size)
 				for _, nr := range facetRequest.NumericRanges {
@@ -660,6 +703,13 @@ func (i *indexImpl) SearchInContext(ctx context.Context, req *SearchRequest) (sr
 		if highlighter == nil {
 			return nil, fmt.Errorf("no highlighter named `%s` registered", *req.Highlight.Style)
 		}
+		highlighter = taggedHighlighter(highlighter, req.Highlight)
+		for _, style := range req.Highlight.FieldStyles {
+			_, err = Config.Cache.HighlighterNamed(style)
+			if err != nil {
+				return nil, err
+			}
+		}
 	}
 
 	var storedFieldsCost uint64
@@ -711,8 +761,22 @@ func (i *indexImpl) SearchInContext(ctx context.Context, req *SearchRequest) (sr
 		Facets:   coll.FacetResults(),
 	}
 
+	if req.IncludeLocations {
+		for _, hit := range hits {
+			hit.MatchedFields = hit.Locations.Fields()
+			hit.Highlights = hit.Locations.Offsets()
+		}
+		rv.MatchedTerms = matchedTerms(hits)
+	}
+
+	trimFragments(req, hits)
+	sortFacetRanges(req, rv.Facets)
+	if req.IncludeWarnings {
+		rv.Warnings = append(unstoredFieldWarnings(req, i.m), queryWarnings...)
+	}
+
 	if req.Explain {
-		rv.Request = req
+		rv.Request = origReq
 	}
 
 	return rv, nil
@@ -730,7 +794,8 @@ func LoadAndHighlightFields(hit *search.DocumentMatch, req *SearchRequest,
 				fieldsToLoad := deDuplicate(req.Fields)
 				for _, f := range fieldsToLoad {
 					doc.VisitFields(func(docF index.Field) {
-						if f == "*" || docF.Name() == f {
+						if fieldMatchesPattern(f, docF.Name()) &&
+							!fieldExcluded(req.ExcludeFields, docF.Name()) {
 							var value interface{}
 							switch docF := docF.(type) {
 							case index.TextField:
@@ -805,7 +870,15 @@ func LoadAndHighlightFields(hit *search.DocumentMatch, req *SearchRequest,
 					}
 				}
 				for _, hf := range highlightFields {
-					highlighter.BestFragmentsInField(hit, doc, hf, 1)
+					fieldHighlighter := highlighter
+					if style, ok := req.Highlight.FieldStyles[hf]; ok {
+						fieldHighlighter, err = Config.Cache.HighlighterNamed(style)
+						if err != nil {
+							return err, 0
+						}
+						fieldHighlighter = taggedHighlighter(fieldHighlighter, req.Highlight)
+					}
+					fieldHighlighter.BestFragmentsInField(hit, doc, hf, 1)
 				}
 			}
 		} else if doc == nil {
@@ -818,6 +891,23 @@ func LoadAndHighlightFields(hit *search.DocumentMatch, req *SearchRequest,
 	return nil, totalStoredFieldsBytes
 }
 
+// taggedHighlighter returns a copy of the highlighter wrapping the
+// matched terms in the tags of the request, when it sets any.
+func taggedHighlighter(h highlight.Highlighter, req *HighlightRequest) highlight.Highlighter {
+	if req.PreTag == "" && req.PostTag == "" {
+		return h
+	}
+	pre, post := req.PreTag, req.PostTag
+	if pre == "" {
+		pre = "<mark>"
+	}
+	if post == "" {
+		post = "</mark>"
+	}
+	return simpleHighlighter.NewHighlighter(h.Fragmenter(),
+		htmlFormatter.NewFragmentFormatter(pre, post), h.Separator())
+}
+
 // Fields returns the name of all the fields this
 // Index has operated on.
 func (i *indexImpl) Fields() (fields []string, err error) {
@@ -1045,6 +1135,26 @@ func deDuplicate(fields []string) []string {
 	return ret
 }
 
+// fieldMatchesPattern reports whether the named field is selected by
+// the pattern, which is either "*", an exact field name or a glob
+// pattern as understood by path.Match.
+func fieldMatchesPattern(pattern, name string) bool {
+	if pattern == "*" || pattern == name {
+		return true
+	}
+	matched, err := path.Match(pattern, name)
+	return err == nil && matched
+}
+
+func fieldExcluded(excludes []string, name string) bool {
+	for _, pattern := range excludes {
+		if fieldMatchesPattern(pattern, name) {
+			return true
+		}
+	}
+	return false
+}
+
 type searchHitSorter struct {
 	hits          search.DocumentMatchCollection
 	sort          search.SortOrder
@@ -1127,3 +1237,110 @@ func (i *indexImpl) FireIndexEvent() {
 		internalEventIndex.FireIndexEvent()
 	}
 }
+
+// compareIndexMappings returns an error wrapping ErrorIndexMappingMismatch
+// and naming the first differing setting, if the requested mapping
+// does not match the mapping an index was created with.  Both mappings
+// are compared in their serialized form, the requested mapping is first
+// round-tripped through JSON so that it is normalized the same way the
+// stored mapping was when the index was opened.
+func compareIndexMappings(stored, requested mapping.IndexMapping) error {
+	requestedBytes, err := util.MarshalJSON(requested)
+	if err != nil {
+		return err
+	}
+	var normalized mapping.IndexMappingImpl
+	err = util.UnmarshalJSON(requestedBytes, &normalized)
+	if err != nil {
+		return err
+	}
+
+	var storedVal, requestedVal interface{}
+	err = mappingJSONValue(stored, &storedVal)
+	if err != nil {
+		return err
+	}
+	err = mappingJSONValue(&normalized, &requestedVal)
+	if err != nil {
+		return err
+	}
+
+	if path, s, r, differs := firstJSONDifference("", storedVal, requestedVal); differs {
+		if path == "" {
+			path = "<root>"
+		}
+		return fmt.Errorf("%w: at '%s' the index has %s, requested %s",
+			ErrorIndexMappingMismatch, path, jsonValueString(s), jsonValueString(r))
+	}
+	return nil
+}
+
+func mappingJSONValue(m mapping.IndexMapping, rv *interface{}) error {
+	b, err := util.MarshalJSON(m)
+	if err != nil {
+		return err
+	}
+	return util.UnmarshalJSON(b, rv)
+}
+
+// firstJSONDifference walks two decoded JSON values in a deterministic
+// order, returning the dotted path and values of the first difference.
+func firstJSONDifference(path string, a, b interface{}) (string, interface{}, interface{}, bool) {
+	switch av := a.(type) {
+	case map[string]interface{}:
+		bv, ok := b.(map[string]interface{})
+		if !ok {
+			return path, a, b, true
+		}
+		keys := make([]string, 0, len(av)+len(bv))
+		for k := range av {
+			keys = append(keys, k)
+		}
+		for k := range bv {
+			if _, exists := av[k]; !exists {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if p, x, y, differs := firstJSONDifference(joinJSONPath(path, k), av[k], bv[k]); differs {
+				return p, x, y, true
+			}
+		}
+		return "", nil, nil, false
+	case []interface{}:
+		bv, ok := b.([]interface{})
+		if !ok || len(av) != len(bv) {
+			return path, a, b, true
+		}
+		for i := range av {
+			if p, x, y, differs := firstJSONDifference(joinJSONPath(path, strconv.Itoa(i)), av[i], bv[i]); differs {
+				return p, x, y, true
+			}
+		}
+		return "", nil, nil, false
+	default:
+		if a != b {
+			return path, a, b, true
+		}
+		return "", nil, nil, false
+	}
+}
+
+func joinJSONPath(path, elem string) string {
+	if path == "" {
+		return elem
+	}
+	return path + "." + elem
+}
+
+func jsonValueString(v interface{}) string {
+	if v == nil {
+		return "nothing"
+	}
+	b, err := util.MarshalJSON(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(b)
+}
@@ -40,6 +40,13 @@ func newIndexMeta(indexType string, storage string, config map[string]interface{
 	}
 }
 
+// isEmptyDir reports whether path is an existing directory
+// without any entries.
+func isEmptyDir(path string) bool {
+	entries, err := os.ReadDir(path)
+	return err == nil && len(entries) == 0
+}
+
 func openIndexMeta(path string) (*indexMeta, error) {
 	if _, err := os.Stat(path); os.IsNotExist(err) {
 		return nil, ErrorIndexPathDoesNotExist
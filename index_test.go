@@ -17,6 +17,7 @@ package bleve
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -771,6 +772,130 @@ func TestIndexOpenMetaMissingOrCorrupt(t *testing.T) {
 	}
 }
 
+func TestIndexOpenOrCreate(t *testing.T) {
+	tmpIndexPath := createTmpIndexPath(t)
+	defer cleanupTmpIndexPath(t, tmpIndexPath)
+
+	buildMapping := func(analyzer string) *mapping.IndexMappingImpl {
+		nameMapping := mapping.NewTextFieldMapping()
+		nameMapping.Analyzer = analyzer
+		docMapping := mapping.NewDocumentMapping()
+		docMapping.AddFieldMappingsAt("name", nameMapping)
+		rv := mapping.NewIndexMapping()
+		rv.DefaultMapping = docMapping
+		return rv
+	}
+
+	// path does not exist, index is created
+	index, err := OpenOrCreate(tmpIndexPath, buildMapping("standard"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = index.Index("1", map[string]interface{}{"name": "marty"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = index.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// same mapping, existing index is opened
+	index, err = OpenOrCreate(tmpIndexPath, buildMapping("standard"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	count, err := index.DocCount()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("expected existing index with 1 document, got %d", count)
+	}
+	err = index.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// changed analyzer, mismatch is reported
+	index, err = OpenOrCreate(tmpIndexPath, buildMapping("keyword"))
+	if !errors.Is(err, ErrorIndexMappingMismatch) {
+		t.Fatalf("expected mapping mismatch error, got %v", err)
+	}
+	if index != nil {
+		t.Errorf("expected no index returned on mismatch")
+	}
+	if !strings.Contains(err.Error(), "analyzer") ||
+		!strings.Contains(err.Error(), `has "standard", requested "keyword"`) {
+		t.Errorf("expected error to describe the analyzer change, got: %v", err)
+	}
+
+	// index was closed on mismatch, so it can be opened again
+	index, err = OpenOrCreate(tmpIndexPath, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = index.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestIndexOpenOrCreateCorrupt(t *testing.T) {
+	tmpIndexPath := createTmpIndexPath(t)
+	defer cleanupTmpIndexPath(t, tmpIndexPath)
+
+	index, err := OpenOrCreate(tmpIndexPath, NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = index.Index("1", map[string]interface{}{"name": "marty"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = index.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// corrupt the metadata, the index must not be recreated
+	metaPath := filepath.Join(tmpIndexPath, "index_meta.json")
+	err = os.WriteFile(metaPath, []byte("corrupt"), 0666)
+	if err != nil {
+		t.Fatal(err)
+	}
+	index, err = OpenOrCreate(tmpIndexPath, NewIndexMapping())
+	if !errors.Is(err, ErrorIndexMetaCorrupt) {
+		t.Fatalf("expected index metadata corrupt error, got %v", err)
+	}
+	if index != nil {
+		t.Errorf("expected no index returned for corrupt index")
+	}
+	if !strings.Contains(err.Error(), "was not recreated") {
+		t.Errorf("expected error to explain the index was not recreated, got: %v", err)
+	}
+	metaBytes, err := os.ReadFile(metaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(metaBytes) != "corrupt" {
+		t.Errorf("expected corrupt metadata to be left untouched")
+	}
+
+	// remove the metadata, the remaining files are not overwritten either
+	err = os.Remove(metaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = OpenOrCreate(tmpIndexPath, NewIndexMapping())
+	if !errors.Is(err, ErrorIndexMetaMissing) {
+		t.Fatalf("expected index metadata missing error, got %v", err)
+	}
+	if _, err = os.Stat(metaPath); !os.IsNotExist(err) {
+		t.Errorf("expected no new index to be created, got %v", err)
+	}
+}
+
 func TestInMemIndex(t *testing.T) {
 
 	index, err := NewMemOnly(NewIndexMapping())
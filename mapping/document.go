@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"net"
 	"reflect"
+	"sort"
 	"time"
 
 	"github.com/blevesearch/bleve/v2/registry"
@@ -52,43 +53,61 @@ type DocumentMapping struct {
 
 func (dm *DocumentMapping) Validate(cache *registry.Cache,
 	parentName string, fieldAliasCtx map[string]*FieldMapping) error {
+	var rv error
+	dm.validate(cache, parentName, fieldAliasCtx, func(err error) bool {
+		rv = err
+		return false
+	})
+	return rv
+}
+
+// validate passes the problems found in the document mapping to
+// report, stopping as soon as report returns false.  It returns
+// false when it was stopped.
+func (dm *DocumentMapping) validate(cache *registry.Cache,
+	parentName string, fieldAliasCtx map[string]*FieldMapping,
+	report func(error) bool) bool {
 	var err error
 	if dm.DefaultAnalyzer != "" {
 		_, err := cache.AnalyzerNamed(dm.DefaultAnalyzer)
-		if err != nil {
-			return err
+		if err != nil && !report(err) {
+			return false
 		}
 	}
-	for propertyName, property := range dm.Properties {
+	propertyNames := make([]string, 0, len(dm.Properties))
+	for propertyName := range dm.Properties {
+		propertyNames = append(propertyNames, propertyName)
+	}
+	sort.Strings(propertyNames)
+	for _, propertyName := range propertyNames {
 		newParent := propertyName
 		if parentName != "" {
 			newParent = fmt.Sprintf("%s.%s", parentName, propertyName)
 		}
-		err = property.Validate(cache, newParent, fieldAliasCtx)
-		if err != nil {
-			return err
+		if !dm.Properties[propertyName].validate(cache, newParent, fieldAliasCtx, report) {
+			return false
 		}
 	}
 	for _, field := range dm.Fields {
 		if field.Analyzer != "" {
 			_, err = cache.AnalyzerNamed(field.Analyzer)
-			if err != nil {
-				return err
+			if err != nil && !report(err) {
+				return false
 			}
 		}
 		if field.DateFormat != "" {
 			_, err = cache.DateTimeParserNamed(field.DateFormat)
-			if err != nil {
-				return err
+			if err != nil && !report(err) {
+				return false
 			}
 		}
 
 		err := validateFieldMapping(field, parentName, fieldAliasCtx)
-		if err != nil {
-			return err
+		if err != nil && !report(err) {
+			return false
 		}
 	}
-	return nil
+	return true
 }
 
 func validateFieldType(field *FieldMapping) error {
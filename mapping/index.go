@@ -17,10 +17,16 @@ package mapping
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
 
 	"github.com/blevesearch/bleve/v2/analysis"
+	"github.com/blevesearch/bleve/v2/analysis/analyzer/custom"
 	"github.com/blevesearch/bleve/v2/analysis/analyzer/standard"
 	"github.com/blevesearch/bleve/v2/analysis/datetime/optional"
+	"github.com/blevesearch/bleve/v2/analysis/token/lowercase"
+	"github.com/blevesearch/bleve/v2/analysis/token/ngram"
+	"github.com/blevesearch/bleve/v2/analysis/token/soundex"
+	"github.com/blevesearch/bleve/v2/analysis/tokenizer/unicode"
 	"github.com/blevesearch/bleve/v2/document"
 	"github.com/blevesearch/bleve/v2/registry"
 	"github.com/blevesearch/bleve/v2/util"
@@ -135,6 +141,56 @@ func (im *IndexMappingImpl) AddCustomAnalyzer(name string, config map[string]int
 	return nil
 }
 
+// AddNgramAnalyzer defines an analyzer which lower cases the words of
+// the text and indexes every substring of them between minLength and
+// maxLength characters long.  Fields using it match on partial words,
+// a term "gres" finds "postgresql", when searched with an analyzer
+// which does not split the query into n-grams itself, for example a
+// MatchQuery with the "simple" analyzer.
+//
+// Every word produces many terms, so the index grows considerably,
+// the more so the wider the range of lengths.  Prefer mapping the
+// text a second time into a dedicated field over replacing the
+// analyzer of the field used for regular searches.
+func (im *IndexMappingImpl) AddNgramAnalyzer(name string, minLength, maxLength int) error {
+	if minLength < 1 || maxLength < minLength {
+		return fmt.Errorf("invalid n-gram lengths %d to %d", minLength, maxLength)
+	}
+	filterName := name + "_" + ngram.Name
+	err := im.AddCustomTokenFilter(filterName, map[string]interface{}{
+		"type": ngram.Name,
+		"min":  float64(minLength),
+		"max":  float64(maxLength),
+	})
+	if err != nil {
+		return err
+	}
+	return im.AddCustomAnalyzer(name, map[string]interface{}{
+		"type":      custom.Name,
+		"tokenizer": unicode.Name,
+		"token_filters": []interface{}{
+			lowercase.Name,
+			filterName,
+		},
+	})
+}
+
+// AddSoundexAnalyzer defines an analyzer which indexes the words of
+// the text by their American Soundex code, so that names which sound
+// alike, "Smith" and "Smyth", match each other.  Soundex is tuned for
+// English names and also matches unrelated words sharing a code, so
+// the analyzer is best used on a dedicated field next to the regular
+// one.  Queries against the field are analyzed the same way.
+func (im *IndexMappingImpl) AddSoundexAnalyzer(name string) error {
+	return im.AddCustomAnalyzer(name, map[string]interface{}{
+		"type":      custom.Name,
+		"tokenizer": unicode.Name,
+		"token_filters": []interface{}{
+			soundex.Name,
+		},
+	})
+}
+
 // AddCustomDateTimeParser defines a custom date time parser for use in this mapping
 func (im *IndexMappingImpl) AddCustomDateTimeParser(name string, config map[string]interface{}) error {
 	_, err := im.cache.DefineDateTimeParser(name, config)
@@ -166,27 +222,49 @@ func NewIndexMapping() *IndexMappingImpl {
 // Validate will walk the entire structure ensuring the following
 // explicitly named and default analyzers can be built
 func (im *IndexMappingImpl) Validate() error {
+	var rv error
+	im.validate(func(err error) bool {
+		rv = err
+		return false
+	})
+	return rv
+}
+
+// ValidationErrors returns all the problems found in the mapping,
+// where Validate stops at the first one.
+func (im *IndexMappingImpl) ValidationErrors() []error {
+	var rv []error
+	im.validate(func(err error) bool {
+		rv = append(rv, err)
+		return true
+	})
+	return rv
+}
+
+func (im *IndexMappingImpl) validate(report func(error) bool) {
 	_, err := im.cache.AnalyzerNamed(im.DefaultAnalyzer)
-	if err != nil {
-		return err
+	if err != nil && !report(err) {
+		return
 	}
 	_, err = im.cache.DateTimeParserNamed(im.DefaultDateTimeParser)
-	if err != nil {
-		return err
+	if err != nil && !report(err) {
+		return
 	}
 
 	fieldAliasCtx := make(map[string]*FieldMapping)
-	err = im.DefaultMapping.Validate(im.cache, "", fieldAliasCtx)
-	if err != nil {
-		return err
+	if !im.DefaultMapping.validate(im.cache, "", fieldAliasCtx, report) {
+		return
 	}
-	for _, docMapping := range im.TypeMapping {
-		err = docMapping.Validate(im.cache, "", fieldAliasCtx)
-		if err != nil {
-			return err
+	docTypes := make([]string, 0, len(im.TypeMapping))
+	for docType := range im.TypeMapping {
+		docTypes = append(docTypes, docType)
+	}
+	sort.Strings(docTypes)
+	for _, docType := range docTypes {
+		if !im.TypeMapping[docType].validate(im.cache, "", fieldAliasCtx, report) {
+			return
 		}
 	}
-	return nil
 }
 
 // AddDocumentMapping sets a custom document mapping for the specified type
@@ -327,6 +405,9 @@ func (im *IndexMappingImpl) MapDocument(doc *document.Document, data interface{}
 	if docMapping.Enabled {
 		walkContext := im.newWalkContext(doc, docMapping)
 		docMapping.walkDocument(data, []string{}, []uint64{}, walkContext)
+		if walkContext.err != nil {
+			return walkContext.err
+		}
 
 		// see if the _all field was disabled
 		allMapping, _ := docMapping.documentMappingForPath("_all")
@@ -344,6 +425,9 @@ type walkContext struct {
 	im              *IndexMappingImpl
 	dm              *DocumentMapping
 	excludedFromAll []string
+	// err is set when a value of the document is rejected,
+	// failing the whole document
+	err error
 }
 
 func (im *IndexMappingImpl) newWalkContext(doc *document.Document, dm *DocumentMapping) *walkContext {
@@ -378,6 +462,12 @@ func (im *IndexMappingImpl) AnalyzerNameForPath(path string) string {
 		}
 	}
 
+	// including fields of the default mapping indexed under another name
+	if field := im.DefaultMapping.fieldDescribedByPath(path); field != nil &&
+		field.Analyzer != "" {
+		return field.Analyzer
+	}
+
 	// next we will try default analyzers for the path
 	pathDecoded := decodePath(path)
 	for _, docMapping := range im.TypeMapping {
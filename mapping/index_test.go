@@ -0,0 +1,50 @@
+//  Copyright (c) 2024 Couchbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 		http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mapping
+
+import (
+	"testing"
+
+	"github.com/blevesearch/bleve/v2/analysis/analyzer/keyword"
+	"github.com/blevesearch/bleve/v2/analysis/analyzer/simple"
+	"github.com/blevesearch/bleve/v2/analysis/analyzer/standard"
+)
+
+func TestAnalyzerNameForRenamedField(t *testing.T) {
+	im := NewIndexMapping()
+
+	// the name property is indexed as name, and again as name_phonetic
+	nameMapping := NewTextFieldMapping()
+	nameMapping.Analyzer = simple.Name
+	namePhoneticMapping := NewTextFieldMapping()
+	namePhoneticMapping.Name = "name_phonetic"
+	namePhoneticMapping.Analyzer = keyword.Name
+	im.DefaultMapping.AddFieldMappingsAt("name", nameMapping, namePhoneticMapping)
+
+	tests := []struct {
+		path     string
+		analyzer string
+	}{
+		{path: "name", analyzer: simple.Name},
+		{path: "name_phonetic", analyzer: keyword.Name},
+		// fields not mapped use the default analyzer
+		{path: "title", analyzer: standard.Name},
+	}
+	for _, test := range tests {
+		if got := im.AnalyzerNameForPath(test.path); got != test.analyzer {
+			t.Errorf("expected analyzer %s for %s, got %s", test.analyzer, test.path, got)
+		}
+	}
+}
@@ -19,6 +19,7 @@ package mapping
 
 import (
 	"fmt"
+	"math"
 	"reflect"
 
 	"github.com/blevesearch/bleve/v2/document"
@@ -34,6 +35,15 @@ var (
 	MaxVectorDims = 4096
 )
 
+// RejectInvalidVectors controls how documents containing a vector
+// which cannot be indexed are handled, such as a vector of the wrong
+// dimension, with NaN or infinite components, or an all-zero vector
+// for a field using cosine similarity.  By default such a vector is
+// skipped and the rest of the document is indexed.  When set to true,
+// mapping the document fails with an error instead.
+// p.s must be set/updated at process init() _only_
+var RejectInvalidVectors = false
+
 func NewVectorFieldMapping() *FieldMapping {
 	return &FieldMapping{
 		Type:         "vector",
@@ -139,6 +149,12 @@ func (fm *FieldMapping) processVector(propertyMightBeVector interface{},
 	vector, ok := processVector(propertyMightBeVector, fm.Dims)
 	// Don't add field to document if vector is invalid
 	if !ok {
+		fm.rejectVector(pathString, fmt.Errorf("invalid vector: "+
+			"expected %d numeric dimensions", fm.Dims), context)
+		return false
+	}
+	if err := validateVectorValues(vector, fm.Similarity); err != nil {
+		fm.rejectVector(pathString, err, context)
 		return false
 	}
 	// normalize raw vector if similarity is cosine
@@ -166,6 +182,12 @@ func (fm *FieldMapping) processVectorBase64(propertyMightBeVectorBase64 interfac
 
 	decodedVector, err := document.DecodeVector(encodedString)
 	if err != nil || len(decodedVector) != fm.Dims {
+		fm.rejectVector(pathString, fmt.Errorf("invalid vector: "+
+			"expected base64 encoding of %d dimensions", fm.Dims), context)
+		return
+	}
+	if err = validateVectorValues(decodedVector, fm.Similarity); err != nil {
+		fm.rejectVector(pathString, err, context)
 		return
 	}
 	// normalize raw vector if similarity is cosine
@@ -183,6 +205,35 @@ func (fm *FieldMapping) processVectorBase64(propertyMightBeVectorBase64 interfac
 	context.excludedFromAll = append(context.excludedFromAll, fieldName)
 }
 
+// rejectVector records the error in the context, failing the
+// document, when RejectInvalidVectors is set.
+func (fm *FieldMapping) rejectVector(pathString string, err error,
+	context *walkContext) {
+	if RejectInvalidVectors && context.err == nil {
+		context.err = fmt.Errorf("field: '%s', %v", pathString, err)
+	}
+}
+
+// validateVectorValues checks that all components of the vector are
+// finite and, for cosine similarity, that it is not a zero vector,
+// which cannot be normalized.
+func validateVectorValues(vec []float32, similarity string) error {
+	zero := true
+	for i, v := range vec {
+		if math.IsNaN(float64(v)) || math.IsInf(float64(v), 0) {
+			return fmt.Errorf("invalid vector: value %v at position %d", v, i)
+		}
+		if v != 0 {
+			zero = false
+		}
+	}
+	if zero && similarity == index.CosineSimilarity {
+		return fmt.Errorf("invalid vector: zero vector cannot be "+
+			"used with %s similarity", index.CosineSimilarity)
+	}
+	return nil
+}
+
 // -----------------------------------------------------------------------------
 // document validation functions
 
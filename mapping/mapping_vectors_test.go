@@ -18,8 +18,13 @@
 package mapping
 
 import (
+	"math"
 	"reflect"
+	"strings"
 	"testing"
+
+	"github.com/blevesearch/bleve/v2/document"
+	index "github.com/blevesearch/bleve_index_api"
 )
 
 func TestVectorFieldAliasValidation(t *testing.T) {
@@ -332,3 +337,68 @@ func TestNormalizeVector(t *testing.T) {
 		}
 	}
 }
+
+func TestInvalidVectorValues(t *testing.T) {
+	vecFieldMapping := NewVectorFieldMapping()
+	vecFieldMapping.Dims = 3
+	vecFieldMapping.Similarity = index.CosineSimilarity
+	m := NewIndexMapping()
+	m.DefaultMapping.AddFieldMappingsAt("vector", vecFieldMapping)
+	err := m.Validate()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		vector []interface{}
+		valid  bool
+	}{
+		{vector: []interface{}{0.1, 0.2, 0.3}, valid: true},
+		{vector: []interface{}{0.0, 0.0, 0.0}, valid: false},
+		{vector: []interface{}{math.NaN(), 0.2, 0.3}, valid: false},
+		{vector: []interface{}{0.1, math.Inf(1), 0.3}, valid: false},
+		{vector: []interface{}{0.1, 0.2}, valid: false},
+	}
+
+	hasVectorField := func(doc *document.Document) bool {
+		for _, f := range doc.Fields {
+			if f.Name() == "vector" {
+				return true
+			}
+		}
+		return false
+	}
+
+	defer func(orig bool) {
+		RejectInvalidVectors = orig
+	}(RejectInvalidVectors)
+
+	for i, test := range tests {
+		data := map[string]interface{}{
+			"name":   "doc",
+			"vector": test.vector,
+		}
+
+		// invalid vectors are skipped by default
+		RejectInvalidVectors = false
+		doc := document.NewDocument("doc")
+		err = m.MapDocument(doc, data)
+		if err != nil {
+			t.Fatalf("[%d] expected no error, got %v", i, err)
+		}
+		if hasVectorField(doc) != test.valid {
+			t.Errorf("[%d] expected vector field indexed: %t", i, test.valid)
+		}
+
+		// or fail the document when rejecting them
+		RejectInvalidVectors = true
+		doc = document.NewDocument("doc")
+		err = m.MapDocument(doc, data)
+		if test.valid && err != nil {
+			t.Errorf("[%d] expected no error, got %v", i, err)
+		}
+		if !test.valid && (err == nil || !strings.Contains(err.Error(), "invalid vector")) {
+			t.Errorf("[%d] expected invalid vector error, got %v", i, err)
+		}
+	}
+}
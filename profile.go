@@ -0,0 +1,89 @@
+//  Copyright (c) 2024 Couchbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 		http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bleve
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/blevesearch/bleve/v2/search/query"
+)
+
+// ClauseProfile reports how long a single clause of a query
+// takes to match documents when searched on its own.  Part tells
+// whether the clause is in the query or in the filter of the
+// request.
+type ClauseProfile struct {
+	Part    string        `json:"part"`
+	Path    string        `json:"path"`
+	Clause  query.Query   `json:"clause"`
+	Matches uint64        `json:"matches"`
+	Took    time.Duration `json:"took"`
+}
+
+// QueryProfile breaks the time taken by a query down by clause.
+type QueryProfile struct {
+	Took    time.Duration    `json:"took"`
+	Clauses []*ClauseProfile `json:"clauses"`
+}
+
+// ProfileQuery times the search of the query of the request,
+// combined with its filter, and then of each clause of the query
+// and of the filter on its own, the clauses being those reported
+// by DiagnoseQuery.  Only matching is timed, no hits are loaded.
+// The clauses of a compound query do not add up to its time, they
+// are searched separately and with no cache shared between
+// searches.  Profiling runs every clause, it is meant for
+// debugging slow queries.
+func ProfileQuery(ctx context.Context, i Index, req *SearchRequest) (*QueryProfile, error) {
+	if req.Query == nil && req.Filter == nil {
+		return nil, fmt.Errorf("search request must have a query or a filter")
+	}
+
+	search := func(q query.Query) (uint64, time.Duration, error) {
+		req := NewSearchRequestOptions(q, 0, 0, false)
+		start := time.Now()
+		res, err := i.SearchInContext(ctx, req)
+		if err != nil {
+			return 0, 0, err
+		}
+		return res.Total, time.Since(start), nil
+	}
+
+	_, took, err := search(req.scoringQuery())
+	if err != nil {
+		return nil, err
+	}
+	rv := &QueryProfile{Took: took}
+	err = walkRequestClauses(req, func(part, path string, q query.Query) error {
+		matches, took, err := search(q)
+		if err != nil {
+			return err
+		}
+		rv.Clauses = append(rv.Clauses, &ClauseProfile{
+			Part:    part,
+			Path:    path,
+			Clause:  q,
+			Matches: matches,
+			Took:    took,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rv, nil
+}
@@ -45,6 +45,14 @@ func NewConjunctionQuery(conjuncts ...query.Query) *query.ConjunctionQuery {
 	return query.NewConjunctionQuery(conjuncts)
 }
 
+// NewConstantScoreQuery creates a new Query which
+// matches the same documents as the wrapped Query,
+// but scores all of them equally, so that only the
+// boost of the query influences their score.
+func NewConstantScoreQuery(q query.Query) *query.ConstantScoreQuery {
+	return query.NewConstantScoreQuery(q)
+}
+
 // NewDateRangeQuery creates a new Query for ranges
 // of date values.
 // Date strings are parsed using the DateTimeParser configured in the
@@ -150,6 +158,16 @@ func NewMatchPhraseQuery(matchPhrase string) *query.MatchPhraseQuery {
 	return query.NewMatchPhraseQuery(matchPhrase)
 }
 
+// NewMatchPhrasePrefixQuery creates a new Query object
+// for matching phrases in the index, treating the
+// last term of the phrase as a prefix. This is
+// useful for search-as-you-type, where the final
+// word may still be incomplete. Queried field must
+// have been indexed with IncludeTermVectors set to true.
+func NewMatchPhrasePrefixQuery(matchPhrasePrefix string) *query.MatchPhrasePrefixQuery {
+	return query.NewMatchPhrasePrefixQuery(matchPhrasePrefix)
+}
+
 // NewMatchQuery creates a Query for matching text.
 // An Analyzer is chosen based on the field.
 // Input text is analyzed using this analyzer.
@@ -391,3 +391,53 @@ func BenchmarkQueryGeoBoundingBox(b *testing.B) {
 		}
 	}
 }
+
+func benchmarkQueryScore(b *testing.B, score string) {
+	tmpIndexPath := createTmpIndexPath(b)
+	defer cleanupTmpIndexPath(b, tmpIndexPath)
+
+	idx, err := New(tmpIndexPath, mapping.NewIndexMapping())
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	defer func() {
+		err = idx.Close()
+		if err != nil {
+			b.Fatal(err)
+		}
+	}()
+
+	members := []string{"abc def", "abc ghi", "def jkl", "abc mno"}
+	batch := idx.NewBatch()
+	for i := 0; i < 10000; i++ {
+		if err = batch.Index(strconv.Itoa(i),
+			map[string]interface{}{"text": members[i%len(members)]}); err != nil {
+			b.Fatal(err)
+		}
+	}
+	if err = idx.Batch(batch); err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		q := NewMatchQuery("abc def")
+		q.SetField("text")
+		req := NewSearchRequest(q)
+		req.Score = score
+		if _, err = idx.Search(req); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkQueryScoreDefault(b *testing.B) {
+	benchmarkQueryScore(b, "")
+}
+
+func BenchmarkQueryScoreNone(b *testing.B) {
+	benchmarkQueryScore(b, ScoreNone)
+}
@@ -15,17 +15,26 @@
 package bleve
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"math"
 	"reflect"
 	"sort"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/blevesearch/bleve/v2/analysis"
 	"github.com/blevesearch/bleve/v2/analysis/datetime/optional"
 	"github.com/blevesearch/bleve/v2/document"
+	"github.com/blevesearch/bleve/v2/mapping"
+	"github.com/blevesearch/bleve/v2/numeric"
 	"github.com/blevesearch/bleve/v2/registry"
 	"github.com/blevesearch/bleve/v2/search"
 	"github.com/blevesearch/bleve/v2/search/collector"
+	"github.com/blevesearch/bleve/v2/search/facet"
 	"github.com/blevesearch/bleve/v2/search/query"
 	"github.com/blevesearch/bleve/v2/size"
 	"github.com/blevesearch/bleve/v2/util"
@@ -45,6 +54,11 @@ var cache = registry.NewCache()
 
 const defaultDateTimeParser = optional.Name
 
+// ScoreNone, as the Score of a SearchRequest, skips computing the
+// scores of the hits, which all score 0.  Searches only filtering
+// documents, or sorting them by their fields, run faster this way.
+const ScoreNone = "none"
+
 type dateTimeRange struct {
 	Name           string    `json:"name,omitempty"`
 	Start          time.Time `json:"start,omitempty"`
@@ -137,6 +151,26 @@ type FacetRequest struct {
 	Field          string           `json:"field"`
 	NumericRanges  []*numericRange  `json:"numeric_ranges,omitempty"`
 	DateTimeRanges []*dateTimeRange `json:"date_ranges,omitempty"`
+	Stats          bool             `json:"stats,omitempty"`
+	// Interval, when positive, builds a histogram of the values
+	// of the numeric field, with buckets of this width.  When Size
+	// is positive, only the Size buckets with the most values are
+	// kept, the values of the others being counted as Other.
+	Interval float64 `json:"interval,omitempty"`
+	// CalendarInterval, one of hour, day, week, month or year,
+	// builds a histogram of the values of the date field.  The
+	// buckets start at midnight in TimeZone, an IANA time zone
+	// name or an offset like "+02:00", UTC by default.
+	CalendarInterval string `json:"calendar_interval,omitempty"`
+	TimeZone         string `json:"time_zone,omitempty"`
+	// SortByBound orders numeric and date ranges by their bounds
+	// instead of by descending count.  The ranges kept when there
+	// are more than Size are still the ones with the highest count.
+	SortByBound bool `json:"sort_by_bound,omitempty"`
+	// Selected restricts the results to the documents falling in
+	// one of these buckets, the terms of a term facet or the names
+	// of the ranges of a range facet.
+	Selected []string `json:"selected,omitempty"`
 }
 
 // NewFacetRequest creates a facet on the specified
@@ -149,12 +183,95 @@ func NewFacetRequest(field string, size int) *FacetRequest {
 	}
 }
 
+// NewStatsFacetRequest creates a facet computing the
+// count, min, max, sum and average of the values of
+// the specified numeric field, over all the documents
+// matching the search.
+func NewStatsFacetRequest(field string) *FacetRequest {
+	return &FacetRequest{
+		Field: field,
+		Stats: true,
+	}
+}
+
+// NewHistogramFacetRequest creates a facet counting the
+// values of the specified numeric field in buckets of
+// the specified width, over all the documents matching
+// the search.
+func NewHistogramFacetRequest(field string, interval float64) *FacetRequest {
+	return &FacetRequest{
+		Field:    field,
+		Interval: interval,
+	}
+}
+
+// NewDateHistogramFacetRequest creates a facet counting
+// the values of the specified date field in calendar
+// buckets of the specified interval, over all the
+// documents matching the search.
+func NewDateHistogramFacetRequest(field string, calendarInterval string) *FacetRequest {
+	return &FacetRequest{
+		Field:            field,
+		CalendarInterval: calendarInterval,
+	}
+}
+
+// location returns the time zone of a date histogram facet.
+func (fr *FacetRequest) location() (*time.Location, error) {
+	if fr.TimeZone == "" {
+		return time.UTC, nil
+	}
+	if t, err := time.Parse("-07:00", fr.TimeZone); err == nil {
+		_, offset := t.Zone()
+		return time.FixedZone(fr.TimeZone, offset), nil
+	}
+	loc, err := time.LoadLocation(fr.TimeZone)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone '%s'", fr.TimeZone)
+	}
+	return loc, nil
+}
+
 func (fr *FacetRequest) Validate() error {
 	nrCount := len(fr.NumericRanges)
 	drCount := len(fr.DateTimeRanges)
 	if nrCount > 0 && drCount > 0 {
 		return fmt.Errorf("facet can only contain numeric ranges or date ranges, not both")
 	}
+	if fr.Stats && (nrCount > 0 || drCount > 0) {
+		return fmt.Errorf("stats facet cannot contain numeric ranges or date ranges")
+	}
+	if fr.Stats && len(fr.Selected) > 0 {
+		return fmt.Errorf("stats facet cannot have selected buckets")
+	}
+	if fr.Interval < 0 {
+		return fmt.Errorf("histogram facet interval must be positive")
+	}
+	if fr.Interval > 0 && (fr.Stats || nrCount > 0 || drCount > 0) {
+		return fmt.Errorf("histogram facet cannot be a stats facet or contain numeric ranges or date ranges")
+	}
+	if fr.Interval > 0 && len(fr.Selected) > 0 {
+		return fmt.Errorf("histogram facet cannot have selected buckets")
+	}
+	if fr.CalendarInterval != "" {
+		if !facet.IsCalendarInterval(fr.CalendarInterval) {
+			return fmt.Errorf("unknown calendar interval '%s'", fr.CalendarInterval)
+		}
+		if _, err := fr.location(); err != nil {
+			return err
+		}
+		if fr.Stats || fr.Interval > 0 || nrCount > 0 || drCount > 0 {
+			return fmt.Errorf("date histogram facet cannot be a stats or histogram facet or contain numeric ranges or date ranges")
+		}
+		if len(fr.Selected) > 0 {
+			return fmt.Errorf("date histogram facet cannot have selected buckets")
+		}
+	}
+	for _, name := range fr.Selected {
+		if (nrCount > 0 || drCount > 0) && fr.rangeNamed(name) == nil {
+			return fmt.Errorf("selected range '%s' is not a range of the facet", name)
+		}
+	}
 
 	if nrCount > 0 {
 		nrNames := map[string]interface{}{}
@@ -254,9 +371,22 @@ func (fr FacetsRequest) Validate() error {
 
 // HighlightRequest describes how field matches
 // should be highlighted.
+// FieldStyles overrides the Style for individual fields.
+// MaxFragmentsPerHit and MaxFragments, when positive, cap
+// the number of fragments returned for each hit and for
+// the whole response.
 type HighlightRequest struct {
-	Style  *string  `json:"style"`
-	Fields []string `json:"fields"`
+	Style              *string           `json:"style"`
+	Fields             []string          `json:"fields"`
+	FieldStyles        map[string]string `json:"field_styles,omitempty"`
+	MaxFragmentsPerHit int               `json:"max_fragments_per_hit,omitempty"`
+	MaxFragments       int               `json:"max_fragments,omitempty"`
+	// PreTag and PostTag, when either is set, wrap the matched
+	// terms of the fragments instead of the markup of the style,
+	// the one left empty defaults to <mark> or </mark>.  The text
+	// of the fragments is HTML escaped.
+	PreTag  string `json:"pre_tag,omitempty"`
+	PostTag string `json:"post_tag,omitempty"`
 }
 
 // NewHighlight creates a default
@@ -273,6 +403,13 @@ func NewHighlightWithStyle(style string) *HighlightRequest {
 	}
 }
 
+// SetTags wraps the matched terms of the fragments in
+// the specified tags.
+func (h *HighlightRequest) SetTags(pre, post string) {
+	h.PreTag = pre
+	h.PostTag = post
+}
+
 func (h *HighlightRequest) AddField(field string) {
 	if h.Fields == nil {
 		h.Fields = make([]string, 0, 1)
@@ -280,13 +417,31 @@ func (h *HighlightRequest) AddField(field string) {
 	h.Fields = append(h.Fields, field)
 }
 
+// SetFieldStyle highlights the field using the named
+// style, instead of the style of the request.
+func (h *HighlightRequest) SetFieldStyle(field, style string) {
+	if h.FieldStyles == nil {
+		h.FieldStyles = make(map[string]string, 1)
+	}
+	h.FieldStyles[field] = style
+}
+
 func (r *SearchRequest) Validate() error {
+	if r.Query == nil && r.Filter == nil {
+		return fmt.Errorf("search request must have a query or a filter")
+	}
 	if srq, ok := r.Query.(query.ValidatableQuery); ok {
 		err := srq.Validate()
 		if err != nil {
 			return err
 		}
 	}
+	if srq, ok := r.Filter.(query.ValidatableQuery); ok {
+		err := srq.Validate()
+		if err != nil {
+			return err
+		}
+	}
 
 	if r.SearchAfter != nil && r.SearchBefore != nil {
 		return fmt.Errorf("cannot use search after and search before together")
@@ -296,7 +451,7 @@ func (r *SearchRequest) Validate() error {
 		if r.From != 0 {
 			return fmt.Errorf("cannot use search after with from !=0")
 		}
-		if len(r.SearchAfter) != len(r.Sort) {
+		if len(r.SearchAfter) != len(r.sortOrder()) {
 			return fmt.Errorf("search after must have same size as sort order")
 		}
 	}
@@ -304,11 +459,16 @@ func (r *SearchRequest) Validate() error {
 		if r.From != 0 {
 			return fmt.Errorf("cannot use search before with from !=0")
 		}
-		if len(r.SearchBefore) != len(r.Sort) {
+		if len(r.SearchBefore) != len(r.sortOrder()) {
 			return fmt.Errorf("search before must have same size as sort order")
 		}
 	}
 
+	if r.FacetMode != "" && r.FacetMode != FacetModePostFilter &&
+		r.FacetMode != FacetModePreFilter {
+		return fmt.Errorf("unknown facet mode '%s'", r.FacetMode)
+	}
+
 	err := validateKNN(r)
 	if err != nil {
 		return err
@@ -324,6 +484,61 @@ func (r *SearchRequest) AddFacet(facetName string, f *FacetRequest) {
 	r.Facets[facetName] = f
 }
 
+// SetFilter restricts the results of the request to documents
+// also matching the filter query.  The filter does not contribute
+// to the score, so a request with only a filter returns every
+// matching document with the same score.
+func (r *SearchRequest) SetFilter(filter query.Query) {
+	r.Filter = filter
+}
+
+// Refine returns a copy of the request searching for the refinement
+// query within the results of the request: the query and filter of
+// the request become the filter of the copy, so they narrow down the
+// documents without contributing to their score, which comes from the
+// refinement alone.  Refinements can be chained.
+func (r *SearchRequest) Refine(refinement query.Query) *SearchRequest {
+	rv := *r
+	rv.Query = refinement
+	switch {
+	case r.Query == nil:
+		rv.Filter = r.Filter
+	case r.Filter == nil:
+		rv.Filter = r.Query
+	default:
+		rv.Filter = query.NewConjunctionQuery([]query.Query{r.Query, r.Filter})
+	}
+	return &rv
+}
+
+// Queries returns the queries of the request which select documents:
+// its query, its filter and the filters of its kNN requests, those
+// which are not set being left out.
+func (r *SearchRequest) Queries() []query.Query {
+	var rv []query.Query
+	for _, q := range append([]query.Query{r.Query, r.Filter}, knnFilterQueries(r)...) {
+		if q != nil {
+			rv = append(rv, q)
+		}
+	}
+	return rv
+}
+
+// scoringQuery returns the query to execute for this request,
+// combining the Query with the Filter, if any.
+func (r *SearchRequest) scoringQuery() query.Query {
+	if r.Filter == nil {
+		return r.Query
+	}
+	q := r.Query
+	if q == nil {
+		q = query.NewMatchAllQuery()
+	}
+	filter := query.NewConstantScoreQuery(r.Filter)
+	filter.SetBoost(0)
+	return query.NewConjunctionQuery([]query.Query{q, filter})
+}
+
 // SortBy changes the request to use the requested sort order
 // this form uses the simplified syntax with an array of strings
 // each string can either be a field name
@@ -339,6 +554,35 @@ func (r *SearchRequest) SortByCustom(order search.SortOrder) {
 	r.Sort = order
 }
 
+// sortOrder returns the sort order of the request, followed by a sort
+// on the document ID when TieBreak is set and the order has none.
+func (r *SearchRequest) sortOrder() search.SortOrder {
+	if !r.TieBreak || len(r.Sort) == 0 {
+		return r.Sort
+	}
+	for _, so := range r.Sort {
+		if _, ok := so.(*search.SortDocID); ok {
+			return r.Sort
+		}
+	}
+	rv := make(search.SortOrder, 0, len(r.Sort)+1)
+	rv = append(rv, r.Sort...)
+	return append(rv, &search.SortDocID{})
+}
+
+// withSortOrder returns the request to execute: r itself, or a shallow
+// copy carrying the effective sort order when it differs from r.Sort,
+// so that the caller's request is never modified.
+func (r *SearchRequest) withSortOrder() *SearchRequest {
+	sortOrder := r.sortOrder()
+	if len(sortOrder) == len(r.Sort) {
+		return r
+	}
+	rv := *r
+	rv.Sort = sortOrder
+	return &rv
+}
+
 // SetSearchAfter sets the request to skip over hits with a sort
 // value less than the provided sort after key
 func (r *SearchRequest) SetSearchAfter(after []string) {
@@ -444,6 +688,18 @@ type SearchResult struct {
 	MaxScore float64                        `json:"max_score"`
 	Took     time.Duration                  `json:"took"`
 	Facets   search.FacetResults            `json:"facets"`
+
+	// MatchedTerms lists, for each field, the indexed terms the
+	// hits matched, such as the variants a fuzzy or prefix query
+	// term expanded to.  It is only computed when locations are
+	// included in the request.
+	MatchedTerms map[string][]string `json:"matched_terms,omitempty"`
+
+	// Warnings reports problems with the request which did not
+	// prevent the search, such as requested fields which are not
+	// stored and whose values can therefore not be returned, when
+	// the request sets IncludeWarnings.
+	Warnings []string `json:"warnings,omitempty"`
 }
 
 func (sr *SearchResult) Size() int {
@@ -503,6 +759,16 @@ func (sr *SearchResult) String() string {
 			for _, d := range f.DateRanges {
 				rv += fmt.Sprintf("\t%s(%d)\n", d.Name, d.Count)
 			}
+			if f.Stats != nil {
+				rv += fmt.Sprintf("\tmin(%g) max(%g) sum(%g) avg(%g)\n",
+					f.Stats.Min, f.Stats.Max, f.Stats.Sum, f.Stats.Avg)
+			}
+			for _, b := range f.Histogram {
+				rv += fmt.Sprintf("\t[%g, %g)(%d)\n", b.Min, b.Max, b.Count)
+			}
+			for _, b := range f.DateHistogram {
+				rv += fmt.Sprintf("\t%s(%d)\n", b.Start.Format(time.RFC3339), b.Count)
+			}
 			if f.Other != 0 {
 				rv += fmt.Sprintf("\tOther(%d)\n", f.Other)
 			}
@@ -511,6 +777,449 @@ func (sr *SearchResult) String() string {
 	return rv
 }
 
+// sortFacetRanges orders the ranges of the facets
+// requesting it by their bounds.
+func sortFacetRanges(req *SearchRequest, facets search.FacetResults) {
+	for name, fr := range req.Facets {
+		if fr.SortByBound {
+			if facetResult, ok := facets[name]; ok {
+				facetResult.SortRangesByBound()
+			}
+		}
+	}
+}
+
+// trimFragments enforces the fragment caps of the highlight request
+// on the hits, in order, so that higher ranked hits keep theirs.
+func trimFragments(req *SearchRequest, hits search.DocumentMatchCollection) {
+	if req.Highlight == nil ||
+		(req.Highlight.MaxFragmentsPerHit <= 0 && req.Highlight.MaxFragments <= 0) {
+		return
+	}
+	remaining := req.Highlight.MaxFragments
+	for _, hit := range hits {
+		limit := req.Highlight.MaxFragmentsPerHit
+		if req.Highlight.MaxFragments > 0 && (limit <= 0 || remaining < limit) {
+			limit = remaining
+		}
+		kept := trimHitFragments(hit, limit)
+		if req.Highlight.MaxFragments > 0 {
+			remaining -= kept
+		}
+	}
+}
+
+// trimHitFragments keeps at most limit fragments of the hit and
+// returns how many were kept.  Fragments are taken in rounds over
+// the fields in name order, the best fragment of every field
+// first, as the fragments of a field are ordered by score.
+func trimHitFragments(hit *search.DocumentMatch, limit int) int {
+	if limit < 0 {
+		limit = 0
+	}
+	fields := make([]string, 0, len(hit.Fragments))
+	for field := range hit.Fragments {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	keep := make(map[string]int, len(fields))
+	kept := 0
+	for round := 0; kept < limit; round++ {
+		added := false
+		for _, field := range fields {
+			if kept < limit && round < len(hit.Fragments[field]) {
+				keep[field]++
+				kept++
+				added = true
+			}
+		}
+		if !added {
+			break
+		}
+	}
+
+	for _, field := range fields {
+		if keep[field] == 0 {
+			delete(hit.Fragments, field)
+		} else {
+			hit.Fragments[field] = hit.Fragments[field][:keep[field]]
+		}
+	}
+	if len(hit.Fragments) == 0 {
+		hit.Fragments = nil
+	}
+	return kept
+}
+
+// RenameFields renames the fields of the hits, each field named
+// by a key of aliases is moved to the name it maps to.  It fails,
+// leaving the hits unchanged, when a renamed field would collide
+// with another field of a hit.
+func (sr *SearchResult) RenameFields(aliases map[string]string) error {
+	renamed := make([]map[string]interface{}, len(sr.Hits))
+	for n, hit := range sr.Hits {
+		if len(hit.Fields) == 0 {
+			continue
+		}
+		fields := make(map[string]interface{}, len(hit.Fields))
+		for name, value := range hit.Fields {
+			if alias, ok := aliases[name]; ok {
+				name = alias
+			}
+			if _, exists := fields[name]; exists {
+				return fmt.Errorf("renaming the fields of hit '%s' "+
+					"yields field '%s' more than once", hit.ID, name)
+			}
+			fields[name] = value
+		}
+		renamed[n] = fields
+	}
+	for n, fields := range renamed {
+		if fields != nil {
+			sr.Hits[n].Fields = fields
+		}
+	}
+	return nil
+}
+
+// TruncateFields shortens the string values of the fields of the hits
+// named by a key of limits to at most the number of characters it maps
+// to, replacing the end of longer values with an ellipsis.  Shorter
+// values, values which are not strings, and fragments are unchanged.
+func (sr *SearchResult) TruncateFields(limits map[string]int) {
+	for _, hit := range sr.Hits {
+		for name, value := range hit.Fields {
+			limit, ok := limits[name]
+			if !ok {
+				continue
+			}
+			switch v := value.(type) {
+			case string:
+				hit.Fields[name] = truncateString(v, limit)
+			case []interface{}:
+				for i, entry := range v {
+					if str, ok := entry.(string); ok {
+						v[i] = truncateString(str, limit)
+					}
+				}
+			}
+		}
+	}
+}
+
+// truncateString returns s shortened to at most limit characters,
+// the last of which is an ellipsis when s is longer.
+func truncateString(s string, limit int) string {
+	if limit < 0 || utf8.RuneCountInString(s) <= limit {
+		return s
+	}
+	if limit == 0 {
+		return ""
+	}
+	runes := []rune(s)
+	return string(runes[:limit-1]) + "…"
+}
+
+// unstoredFieldWarnings returns a warning for each field requested
+// by name which the mapping does not store, so that its values are
+// never returned.
+func unstoredFieldWarnings(req *SearchRequest, m mapping.IndexMapping) []string {
+	var rv []string
+	for _, field := range req.Fields {
+		if strings.ContainsAny(field, "*?[") {
+			// patterns only return what is stored
+			continue
+		}
+		fm := m.FieldMappingForPath(field)
+		stored := fm.Store
+		if fm.Type == "" {
+			// not mapped explicitly, indexed dynamically if at all
+			im, ok := m.(*mapping.IndexMappingImpl)
+			stored = !ok || im.StoreDynamic
+		}
+		if !stored {
+			rv = append(rv, fmt.Sprintf("field '%s' is not stored, "+
+				"its values cannot be returned", field))
+		}
+	}
+	return rv
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizeScores sets the NormalizedScore of the hits to their
+// score divided by the maximum score of the search, so that the
+// best match scores 1.  The scores are left at 0 when the maximum
+// score is not positive.
+func (sr *SearchResult) NormalizeScores() {
+	if sr.MaxScore <= 0 {
+		return
+	}
+	for _, hit := range sr.Hits {
+		hit.NormalizedScore = hit.Score / sr.MaxScore
+	}
+}
+
+// MarkRelevant sets the Relevant flag of the hits, to true when their
+// score is at least threshold and to false otherwise.  Unlike a minimum
+// score, the hits below the threshold are kept in the result.
+func (sr *SearchResult) MarkRelevant(threshold float64) {
+	for _, hit := range sr.Hits {
+		relevant := hit.Score >= threshold
+		hit.Relevant = &relevant
+	}
+}
+
+// ComputeChecksums sets the Checksum of each hit to the hex encoded
+// SHA-256 hash of its returned fields, so that clients can detect
+// which documents changed between searches.  The hash is computed from
+// the field values in the order of the sorted field names, so it only
+// changes when a returned field does.  Hits without returned fields
+// get no checksum.
+func (sr *SearchResult) ComputeChecksums() error {
+	for _, hit := range sr.Hits {
+		if len(hit.Fields) == 0 {
+			continue
+		}
+		// encoding/json sorts the keys of maps, unlike the
+		// configurable util.MarshalJSON
+		buf, err := json.Marshal(hit.Fields)
+		if err != nil {
+			return err
+		}
+		sum := sha256.Sum256(buf)
+		hit.Checksum = hex.EncodeToString(sum[:])
+	}
+	return nil
+}
+
+// Pagination describes the page of hits of a search result within
+// all its hits, pages are numbered from 1.
+type Pagination struct {
+	Page       int  `json:"page"`
+	PageSize   int  `json:"page_size"`
+	TotalPages int  `json:"total_pages"`
+	HasNext    bool `json:"has_next"`
+	HasPrev    bool `json:"has_prev"`
+}
+
+// Pagination computes the page of the result from its total number
+// of hits and the From and Size of the request which produced it.
+// When From is not a multiple of Size the page is the one containing
+// the first hit.
+func (sr *SearchResult) Pagination(req *SearchRequest) *Pagination {
+	total := int(sr.Total)
+	rv := &Pagination{
+		PageSize: req.Size,
+		HasNext:  req.From+req.Size < total,
+		HasPrev:  req.From > 0,
+	}
+	if req.Size > 0 {
+		rv.Page = req.From/req.Size + 1
+		rv.TotalPages = (total + req.Size - 1) / req.Size
+	}
+	return rv
+}
+
+// RerankByFreshness reorders the hits by a blend of their relevance
+// and their recency, freshness in [0, 1] being the weight of recency:
+// 0 ranks by score alone, 1 by the date alone.  Relevance is the score
+// divided by the maximum score, recency the position of the date of
+// the hit between the oldest and newest dates of the hits.  The dates
+// are read from the named field, which must be among the returned
+// fields, hits without a date in RFC3339 format have a recency of 0.
+// The scores of the hits are unchanged.
+func (sr *SearchResult) RerankByFreshness(field string, freshness float64) error {
+	if freshness < 0 || freshness > 1 {
+		return fmt.Errorf("freshness must be between 0 and 1, got %g", freshness)
+	}
+
+	dates := make([]time.Time, len(sr.Hits))
+	var oldest, newest time.Time
+	for i, hit := range sr.Hits {
+		value, ok := hit.Fields[field].(string)
+		if !ok {
+			continue
+		}
+		date, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			continue
+		}
+		dates[i] = date
+		if oldest.IsZero() || date.Before(oldest) {
+			oldest = date
+		}
+		if newest.IsZero() || date.After(newest) {
+			newest = date
+		}
+	}
+
+	blended := make(map[*search.DocumentMatch]float64, len(sr.Hits))
+	span := newest.Sub(oldest)
+	for i, hit := range sr.Hits {
+		var relevance, recency float64
+		if sr.MaxScore > 0 {
+			relevance = hit.Score / sr.MaxScore
+		}
+		if !dates[i].IsZero() {
+			recency = 1
+			if span > 0 {
+				recency = float64(dates[i].Sub(oldest)) / float64(span)
+			}
+		}
+		blended[hit] = (1-freshness)*relevance + freshness*recency
+	}
+	sort.SliceStable(sr.Hits, func(i, j int) bool {
+		return blended[sr.Hits[i]] > blended[sr.Hits[j]]
+	})
+	return nil
+}
+
+const (
+	// BoostModifierLinear multiplies the score by 1 + factor * value.
+	BoostModifierLinear = "linear"
+	// BoostModifierLog multiplies the score by 1 + log(1 + factor * value).
+	BoostModifierLog = "log"
+	// BoostModifierSqrt multiplies the score by 1 + sqrt(factor * value).
+	BoostModifierSqrt = "sqrt"
+)
+
+// BoostByField multiplies the score of each hit by a function of the
+// numeric value of the named field, such as a popularity count, and
+// reorders the hits by their new score.  The values are read from the
+// doc values of the field in the index i, so the field need neither
+// be stored nor returned.  Hits without a value, or with a negative
+// one, are treated as having a value of 0, which leaves their score
+// unchanged.  The modifier is one of BoostModifierLinear,
+// BoostModifierLog or BoostModifierSqrt.
+// Only the hits of the result are reordered, so a hit boosted past
+// the ones of the previous page is not moved to that page, and the
+// order across pages is not consistent.
+func (sr *SearchResult) BoostByField(i Index, field, modifier string, factor float64) error {
+	var fn func(float64) float64
+	switch modifier {
+	case BoostModifierLinear:
+		fn = func(v float64) float64 { return v }
+	case BoostModifierLog:
+		fn = math.Log1p
+	case BoostModifierSqrt:
+		fn = math.Sqrt
+	default:
+		return fmt.Errorf("unknown boost modifier '%s'", modifier)
+	}
+	if factor <= 0 {
+		return fmt.Errorf("boost factor must be positive, got %g", factor)
+	}
+
+	values, err := numericDocValues(i, field, sr.Hits)
+	if err != nil {
+		return err
+	}
+
+	sr.MaxScore = 0
+	for _, hit := range sr.Hits {
+		value := values[hit]
+		if value < 0 {
+			value = 0
+		}
+		hit.Score *= 1 + fn(factor*value)
+		if hit.Score > sr.MaxScore {
+			sr.MaxScore = hit.Score
+		}
+	}
+	sort.SliceStable(sr.Hits, func(i, j int) bool {
+		return sr.Hits[i].Score > sr.Hits[j].Score
+	})
+	return nil
+}
+
+// numericDocValues returns the first numeric doc value of the field
+// for each of the hits having one, looking them up by document ID.
+func numericDocValues(i Index, field string, hits search.DocumentMatchCollection) (
+	map[*search.DocumentMatch]float64, error) {
+	idx, err := i.Advanced()
+	if err != nil {
+		return nil, err
+	}
+	reader, err := idx.Reader()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = reader.Close()
+	}()
+	dvReader, err := reader.DocValueReader([]string{field})
+	if err != nil {
+		return nil, err
+	}
+
+	rv := make(map[*search.DocumentMatch]float64, len(hits))
+	for _, hit := range hits {
+		internalID, err := reader.InternalID(hit.ID)
+		if err != nil {
+			return nil, err
+		}
+		if internalID == nil {
+			continue
+		}
+		found := false
+		err = dvReader.VisitDocValues(internalID, func(f string, term []byte) {
+			if found || f != field {
+				return
+			}
+			// only consider the values which are shifted 0
+			prefixCoded := numeric.PrefixCoded(term)
+			if shift, err := prefixCoded.Shift(); err != nil || shift != 0 {
+				return
+			}
+			if i64, err := prefixCoded.Int64(); err == nil {
+				rv[hit] = numeric.Int64ToFloat64(i64)
+				found = true
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return rv, nil
+}
+
+// matchedTerms collects the sorted list of distinct terms
+// found in the locations of the hits, for each field.
+func matchedTerms(hits search.DocumentMatchCollection) map[string][]string {
+	var rv map[string][]string
+	seen := make(map[string]map[string]struct{})
+	for _, hit := range hits {
+		for field, termLocations := range hit.Locations {
+			for term := range termLocations {
+				if seen[field] == nil {
+					seen[field] = make(map[string]struct{})
+				}
+				if _, exists := seen[field][term]; exists {
+					continue
+				}
+				seen[field][term] = struct{}{}
+				if rv == nil {
+					rv = make(map[string][]string)
+				}
+				rv[field] = append(rv[field], term)
+			}
+		}
+	}
+	for _, terms := range rv {
+		sort.Strings(terms)
+	}
+	return rv
+}
+
 // Merge will merge together multiple SearchResults during a MultiSearch
 func (sr *SearchResult) Merge(other *SearchResult) {
 	sr.Status.Merge(other.Status)
@@ -520,6 +1229,11 @@ func (sr *SearchResult) Merge(other *SearchResult) {
 	if other.MaxScore > sr.MaxScore {
 		sr.MaxScore = other.MaxScore
 	}
+	for _, warning := range other.Warnings {
+		if !containsString(sr.Warnings, warning) {
+			sr.Warnings = append(sr.Warnings, warning)
+		}
+	}
 	if sr.Facets == nil && len(other.Facets) != 0 {
 		sr.Facets = other.Facets
 		return
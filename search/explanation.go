@@ -18,6 +18,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"strings"
 
 	"github.com/blevesearch/bleve/v2/size"
 )
@@ -43,6 +44,25 @@ func (expl *Explanation) String() string {
 	return string(js)
 }
 
+// Tree renders the explanation as an indented text tree, one
+// line per node with its value followed by its message, and the
+// children of a node indented below it.
+func (expl *Explanation) Tree() string {
+	var sb strings.Builder
+	expl.writeTree(&sb, 0)
+	return sb.String()
+}
+
+func (expl *Explanation) writeTree(sb *strings.Builder, depth int) {
+	if expl == nil {
+		return
+	}
+	fmt.Fprintf(sb, "%s%f %s\n", strings.Repeat("  ", depth), expl.Value, expl.Message)
+	for _, child := range expl.Children {
+		child.writeTree(sb, depth+1)
+	}
+}
+
 func (expl *Explanation) Size() int {
 	sizeInBytes := reflectStaticSizeExplanation + size.SizeOfPtr +
 		len(expl.Message)
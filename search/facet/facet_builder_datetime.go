@@ -98,12 +98,18 @@ func (fb *DateTimeFacetBuilder) UpdateVisitor(term []byte) {
 			t := time.Unix(0, i64)
 
 			// look at each of the ranges for a match
+			matched := false
 			for rangeName, r := range fb.ranges {
 				if (r.start.IsZero() || t.After(r.start) || t.Equal(r.start)) && (r.end.IsZero() || t.Before(r.end)) {
 					fb.termsCount[rangeName] = fb.termsCount[rangeName] + 1
 					fb.total++
+					matched = true
 				}
 			}
+			// values outside all of the ranges are reported as other
+			if !matched {
+				fb.total++
+			}
 		}
 	}
 }
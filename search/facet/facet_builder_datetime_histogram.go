@@ -0,0 +1,168 @@
+//  Copyright (c) 2024 Couchbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 		http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package facet
+
+import (
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/blevesearch/bleve/v2/numeric"
+	"github.com/blevesearch/bleve/v2/search"
+	"github.com/blevesearch/bleve/v2/size"
+)
+
+var reflectStaticSizeDateTimeHistogramFacetBuilder int
+
+func init() {
+	var dthfb DateTimeHistogramFacetBuilder
+	reflectStaticSizeDateTimeHistogramFacetBuilder = int(reflect.TypeOf(dthfb).Size())
+}
+
+// The calendar intervals of a date histogram, weeks start on Monday.
+const (
+	CalendarIntervalHour  = "hour"
+	CalendarIntervalDay   = "day"
+	CalendarIntervalWeek  = "week"
+	CalendarIntervalMonth = "month"
+	CalendarIntervalYear  = "year"
+)
+
+// IsCalendarInterval returns true if the interval is one of the
+// calendar intervals of a date histogram.
+func IsCalendarInterval(interval string) bool {
+	switch interval {
+	case CalendarIntervalHour, CalendarIntervalDay, CalendarIntervalWeek,
+		CalendarIntervalMonth, CalendarIntervalYear:
+		return true
+	}
+	return false
+}
+
+// DateTimeHistogramFacetBuilder counts the values of a date field
+// in calendar buckets, a day, a week, a month..., starting at
+// midnight in the location of the builder.  Only buckets containing
+// values are returned.
+type DateTimeHistogramFacetBuilder struct {
+	field    string
+	interval string
+	location *time.Location
+	counts   map[int64]int
+	total    int
+	missing  int
+	sawValue bool
+}
+
+func NewDateTimeHistogramFacetBuilder(field, interval string, location *time.Location) *DateTimeHistogramFacetBuilder {
+	return &DateTimeHistogramFacetBuilder{
+		field:    field,
+		interval: interval,
+		location: location,
+		counts:   make(map[int64]int),
+	}
+}
+
+func (fb *DateTimeHistogramFacetBuilder) Size() int {
+	return reflectStaticSizeDateTimeHistogramFacetBuilder + size.SizeOfPtr +
+		len(fb.field) + len(fb.interval) +
+		len(fb.counts)*(size.SizeOfUint64+size.SizeOfInt)
+}
+
+func (fb *DateTimeHistogramFacetBuilder) Field() string {
+	return fb.field
+}
+
+// bucketStart returns the start of the bucket containing t.
+func (fb *DateTimeHistogramFacetBuilder) bucketStart(t time.Time) time.Time {
+	t = t.In(fb.location)
+	year, month, day := t.Date()
+	switch fb.interval {
+	case CalendarIntervalHour:
+		return time.Date(year, month, day, t.Hour(), 0, 0, 0, fb.location)
+	case CalendarIntervalWeek:
+		// days since monday
+		offset := (int(t.Weekday()) + 6) % 7
+		return time.Date(year, month, day-offset, 0, 0, 0, 0, fb.location)
+	case CalendarIntervalMonth:
+		return time.Date(year, month, 1, 0, 0, 0, 0, fb.location)
+	case CalendarIntervalYear:
+		return time.Date(year, time.January, 1, 0, 0, 0, 0, fb.location)
+	}
+	return time.Date(year, month, day, 0, 0, 0, 0, fb.location)
+}
+
+// bucketEnd returns the start of the bucket following the one
+// starting at start.
+func (fb *DateTimeHistogramFacetBuilder) bucketEnd(start time.Time) time.Time {
+	switch fb.interval {
+	case CalendarIntervalHour:
+		return start.Add(time.Hour)
+	case CalendarIntervalWeek:
+		return start.AddDate(0, 0, 7)
+	case CalendarIntervalMonth:
+		return start.AddDate(0, 1, 0)
+	case CalendarIntervalYear:
+		return start.AddDate(1, 0, 0)
+	}
+	return start.AddDate(0, 0, 1)
+}
+
+func (fb *DateTimeHistogramFacetBuilder) UpdateVisitor(term []byte) {
+	fb.sawValue = true
+	// only consider the values which are shifted 0
+	prefixCoded := numeric.PrefixCoded(term)
+	shift, err := prefixCoded.Shift()
+	if err == nil && shift == 0 {
+		i64, err := prefixCoded.Int64()
+		if err == nil {
+			start := fb.bucketStart(time.Unix(0, i64))
+			fb.counts[start.UnixNano()]++
+			fb.total++
+		}
+	}
+}
+
+func (fb *DateTimeHistogramFacetBuilder) StartDoc() {
+	fb.sawValue = false
+}
+
+func (fb *DateTimeHistogramFacetBuilder) EndDoc() {
+	if !fb.sawValue {
+		fb.missing++
+	}
+}
+
+func (fb *DateTimeHistogramFacetBuilder) Result() *search.FacetResult {
+	rv := search.FacetResult{
+		Field:         fb.field,
+		Total:         fb.total,
+		Missing:       fb.missing,
+		DateHistogram: make(search.DateHistogramBuckets, 0, len(fb.counts)),
+	}
+
+	for nanos, count := range fb.counts {
+		start := time.Unix(0, nanos).In(fb.location)
+		rv.DateHistogram = append(rv.DateHistogram, &search.DateHistogramBucket{
+			Start: start,
+			End:   fb.bucketEnd(start),
+			Count: count,
+		})
+	}
+	sort.Slice(rv.DateHistogram, func(i, j int) bool {
+		return rv.DateHistogram[i].Start.Before(rv.DateHistogram[j].Start)
+	})
+
+	return &rv
+}
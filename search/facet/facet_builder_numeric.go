@@ -97,12 +97,18 @@ func (fb *NumericFacetBuilder) UpdateVisitor(term []byte) {
 			f64 := numeric.Int64ToFloat64(i64)
 
 			// look at each of the ranges for a match
+			matched := false
 			for rangeName, r := range fb.ranges {
 				if (r.min == nil || f64 >= *r.min) && (r.max == nil || f64 < *r.max) {
 					fb.termsCount[rangeName] = fb.termsCount[rangeName] + 1
 					fb.total++
+					matched = true
 				}
 			}
+			// values outside all of the ranges are reported as other
+			if !matched {
+				fb.total++
+			}
 		}
 	}
 }
@@ -0,0 +1,144 @@
+//  Copyright (c) 2024 Couchbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 		http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package facet
+
+import (
+	"math"
+	"reflect"
+	"sort"
+
+	"github.com/blevesearch/bleve/v2/numeric"
+	"github.com/blevesearch/bleve/v2/search"
+	"github.com/blevesearch/bleve/v2/size"
+)
+
+var reflectStaticSizeNumericHistogramFacetBuilder int
+
+func init() {
+	var nhfb NumericHistogramFacetBuilder
+	reflectStaticSizeNumericHistogramFacetBuilder = int(reflect.TypeOf(nhfb).Size())
+}
+
+// MaxNumericHistogramBuckets bounds the number of buckets a numeric
+// histogram facet counts values in, the values which would fall in
+// further buckets are only counted as other.
+var MaxNumericHistogramBuckets = 10000
+
+// NumericHistogramFacetBuilder counts the values of a numeric
+// field in buckets of equal width, aligned on multiples of the
+// interval.  Only buckets containing values are returned, and
+// when size is positive, only the size buckets with the most
+// values.
+type NumericHistogramFacetBuilder struct {
+	field    string
+	interval float64
+	size     int
+	counts   map[int64]int
+	total    int
+	missing  int
+	other    int
+	sawValue bool
+}
+
+func NewNumericHistogramFacetBuilder(field string, interval float64, size int) *NumericHistogramFacetBuilder {
+	return &NumericHistogramFacetBuilder{
+		field:    field,
+		interval: interval,
+		size:     size,
+		counts:   make(map[int64]int),
+	}
+}
+
+func (fb *NumericHistogramFacetBuilder) Size() int {
+	return reflectStaticSizeNumericHistogramFacetBuilder + size.SizeOfPtr +
+		len(fb.field) +
+		len(fb.counts)*(size.SizeOfUint64+size.SizeOfInt)
+}
+
+func (fb *NumericHistogramFacetBuilder) Field() string {
+	return fb.field
+}
+
+func (fb *NumericHistogramFacetBuilder) UpdateVisitor(term []byte) {
+	fb.sawValue = true
+	// only consider the values which are shifted 0
+	prefixCoded := numeric.PrefixCoded(term)
+	shift, err := prefixCoded.Shift()
+	if err == nil && shift == 0 {
+		i64, err := prefixCoded.Int64()
+		if err == nil {
+			f64 := numeric.Int64ToFloat64(i64)
+			fb.total++
+			// buckets are keyed by their index, so that values in the
+			// same bucket always share the same key
+			index := math.Floor(f64 / fb.interval)
+			if index < math.MinInt64 || index >= math.MaxInt64 {
+				fb.other++
+				return
+			}
+			bucket := int64(index)
+			// keep the value within the bounds reported for its bucket
+			// despite the rounding of the division
+			if float64(bucket)*fb.interval > f64 {
+				bucket--
+			} else if float64(bucket+1)*fb.interval <= f64 {
+				bucket++
+			}
+			if _, exists := fb.counts[bucket]; exists || len(fb.counts) < MaxNumericHistogramBuckets {
+				fb.counts[bucket]++
+			} else {
+				fb.other++
+			}
+		}
+	}
+}
+
+func (fb *NumericHistogramFacetBuilder) StartDoc() {
+	fb.sawValue = false
+}
+
+func (fb *NumericHistogramFacetBuilder) EndDoc() {
+	if !fb.sawValue {
+		fb.missing++
+	}
+}
+
+func (fb *NumericHistogramFacetBuilder) Result() *search.FacetResult {
+	rv := search.FacetResult{
+		Field:     fb.field,
+		Total:     fb.total,
+		Missing:   fb.missing,
+		Other:     fb.other,
+		Histogram: make(search.HistogramBuckets, 0, len(fb.counts)),
+	}
+
+	for bucket, count := range fb.counts {
+		rv.Histogram = append(rv.Histogram, &search.HistogramBucket{
+			Min:   float64(bucket) * fb.interval,
+			Max:   float64(bucket+1) * fb.interval,
+			Count: count,
+		})
+	}
+	sort.Slice(rv.Histogram, func(i, j int) bool {
+		return rv.Histogram[i].Min < rv.Histogram[j].Min
+	})
+	if fb.size > 0 {
+		var other int
+		rv.Histogram, other = rv.Histogram.Trim(fb.size)
+		rv.Other += other
+	}
+
+	return &rv
+}
@@ -0,0 +1,86 @@
+//  Copyright (c) 2024 Couchbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 		http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package facet
+
+import (
+	"testing"
+
+	"github.com/blevesearch/bleve/v2/numeric"
+)
+
+func numericHistogramFacet(interval float64, size int, values ...float64) *NumericHistogramFacetBuilder {
+	fb := NewNumericHistogramFacetBuilder("price", interval, size)
+	for _, v := range values {
+		fb.StartDoc()
+		fb.UpdateVisitor(numeric.MustNewPrefixCodedInt64(numeric.Float64ToInt64(v), 0))
+		fb.EndDoc()
+	}
+	return fb
+}
+
+func TestNumericHistogramFacetBucketBounds(t *testing.T) {
+	values := []float64{0.1 * 3, 0.3, 0.35, 0.7}
+	res := numericHistogramFacet(0.1, 0, values...).Result()
+	if res.Total != len(values) {
+		t.Errorf("expected %d values, got %d", len(values), res.Total)
+	}
+	count := 0
+	for _, bucket := range res.Histogram {
+		count += bucket.Count
+	}
+	if count != len(values) {
+		t.Errorf("expected all the values in buckets, got %d", count)
+	}
+	for _, v := range values {
+		found := 0
+		for _, bucket := range res.Histogram {
+			if bucket.Min <= v && v < bucket.Max {
+				found++
+			}
+		}
+		if found != 1 {
+			t.Errorf("expected %g in exactly one bucket, found in %d", v, found)
+		}
+	}
+}
+
+func TestNumericHistogramFacetSize(t *testing.T) {
+	res := numericHistogramFacet(10, 2, 1, 2, 3, 15, 25, 26).Result()
+	if len(res.Histogram) != 2 {
+		t.Fatalf("expected 2 buckets, got %d", len(res.Histogram))
+	}
+	if res.Histogram[0].Min != 0 || res.Histogram[0].Count != 3 ||
+		res.Histogram[1].Min != 20 || res.Histogram[1].Count != 2 {
+		t.Errorf("expected the fullest buckets in order, got %v, %v", res.Histogram[0], res.Histogram[1])
+	}
+	if res.Other != 1 {
+		t.Errorf("expected 1 other value, got %d", res.Other)
+	}
+}
+
+func TestNumericHistogramFacetMaxBuckets(t *testing.T) {
+	defer func(max int) {
+		MaxNumericHistogramBuckets = max
+	}(MaxNumericHistogramBuckets)
+	MaxNumericHistogramBuckets = 3
+
+	res := numericHistogramFacet(1, 0, 1, 2, 3, 4, 5, 1).Result()
+	if len(res.Histogram) != 3 {
+		t.Errorf("expected 3 buckets, got %d", len(res.Histogram))
+	}
+	if res.Total != 6 || res.Other != 2 {
+		t.Errorf("expected 6 values with 2 other, got %d and %d", res.Total, res.Other)
+	}
+}
@@ -0,0 +1,88 @@
+//  Copyright (c) 2024 Couchbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 		http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package facet
+
+import (
+	"reflect"
+
+	"github.com/blevesearch/bleve/v2/numeric"
+	"github.com/blevesearch/bleve/v2/search"
+	"github.com/blevesearch/bleve/v2/size"
+)
+
+var reflectStaticSizeNumericStatsFacetBuilder int
+
+func init() {
+	var nsfb NumericStatsFacetBuilder
+	reflectStaticSizeNumericStatsFacetBuilder = int(reflect.TypeOf(nsfb).Size())
+}
+
+// NumericStatsFacetBuilder computes the count, min, max, sum
+// and average of the values of a numeric field, over all the
+// documents matching the search.
+type NumericStatsFacetBuilder struct {
+	field    string
+	stats    search.NumericStats
+	missing  int
+	sawValue bool
+}
+
+func NewNumericStatsFacetBuilder(field string) *NumericStatsFacetBuilder {
+	return &NumericStatsFacetBuilder{
+		field: field,
+	}
+}
+
+func (fb *NumericStatsFacetBuilder) Size() int {
+	return reflectStaticSizeNumericStatsFacetBuilder + size.SizeOfPtr +
+		len(fb.field)
+}
+
+func (fb *NumericStatsFacetBuilder) Field() string {
+	return fb.field
+}
+
+func (fb *NumericStatsFacetBuilder) UpdateVisitor(term []byte) {
+	fb.sawValue = true
+	// only consider the values which are shifted 0
+	prefixCoded := numeric.PrefixCoded(term)
+	shift, err := prefixCoded.Shift()
+	if err == nil && shift == 0 {
+		i64, err := prefixCoded.Int64()
+		if err == nil {
+			fb.stats.Add(numeric.Int64ToFloat64(i64))
+		}
+	}
+}
+
+func (fb *NumericStatsFacetBuilder) StartDoc() {
+	fb.sawValue = false
+}
+
+func (fb *NumericStatsFacetBuilder) EndDoc() {
+	if !fb.sawValue {
+		fb.missing++
+	}
+}
+
+func (fb *NumericStatsFacetBuilder) Result() *search.FacetResult {
+	stats := fb.stats
+	return &search.FacetResult{
+		Field:   fb.field,
+		Total:   stats.Count,
+		Missing: fb.missing,
+		Stats:   &stats,
+	}
+}
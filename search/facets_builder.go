@@ -17,6 +17,7 @@ package search
 import (
 	"reflect"
 	"sort"
+	"time"
 
 	"github.com/blevesearch/bleve/v2/size"
 	"github.com/blevesearch/bleve/v2/util"
@@ -28,6 +29,9 @@ var reflectStaticSizeFacetResult int
 var reflectStaticSizeTermFacet int
 var reflectStaticSizeNumericRangeFacet int
 var reflectStaticSizeDateRangeFacet int
+var reflectStaticSizeNumericStats int
+var reflectStaticSizeHistogramBucket int
+var reflectStaticSizeDateHistogramBucket int
 
 func init() {
 	var fb FacetsBuilder
@@ -40,6 +44,12 @@ func init() {
 	reflectStaticSizeNumericRangeFacet = int(reflect.TypeOf(nrf).Size())
 	var drf DateRangeFacet
 	reflectStaticSizeDateRangeFacet = int(reflect.TypeOf(drf).Size())
+	var ns NumericStats
+	reflectStaticSizeNumericStats = int(reflect.TypeOf(ns).Size())
+	var hb HistogramBucket
+	reflectStaticSizeHistogramBucket = int(reflect.TypeOf(hb).Size())
+	var dhb DateHistogramBucket
+	reflectStaticSizeDateHistogramBucket = int(reflect.TypeOf(dhb).Size())
 }
 
 type FacetBuilder interface {
@@ -246,6 +256,46 @@ func (nrf NumericRangeFacets) Less(i, j int) bool {
 	return nrf[i].Count > nrf[j].Count
 }
 
+// SortByBound orders the ranges by ascending lower bound, ranges
+// without a lower bound come first.  Ranges with the same lower
+// bound are ordered by upper bound, ranges without one last.
+func (nrf NumericRangeFacets) SortByBound() {
+	sort.SliceStable(nrf, func(i, j int) bool {
+		if c := compareBounds(nrf[i].Min, nrf[j].Min, true); c != 0 {
+			return c < 0
+		}
+		if c := compareBounds(nrf[i].Max, nrf[j].Max, false); c != 0 {
+			return c < 0
+		}
+		return nrf[i].Name < nrf[j].Name
+	})
+}
+
+// compareBounds compares two optional range bounds, a missing
+// bound is unbounded, so it sorts first when it is a lower bound
+// and last when it is an upper bound.
+func compareBounds(a, b *float64, lower bool) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		if lower {
+			return -1
+		}
+		return 1
+	case b == nil:
+		if lower {
+			return 1
+		}
+		return -1
+	case *a < *b:
+		return -1
+	case *a > *b:
+		return 1
+	}
+	return 0
+}
+
 type DateRangeFacet struct {
 	Name  string  `json:"name"`
 	Start *string `json:"start,omitempty"`
@@ -299,28 +349,212 @@ func (drf DateRangeFacets) Less(i, j int) bool {
 	return drf[i].Count > drf[j].Count
 }
 
+// SortByBound orders the ranges by ascending start, ranges
+// without a start come first.  Ranges with the same start
+// are ordered by end, ranges without one last.
+func (drf DateRangeFacets) SortByBound() {
+	sort.SliceStable(drf, func(i, j int) bool {
+		if c := compareBounds(dateBound(drf[i].Start), dateBound(drf[j].Start), true); c != 0 {
+			return c < 0
+		}
+		if c := compareBounds(dateBound(drf[i].End), dateBound(drf[j].End), false); c != 0 {
+			return c < 0
+		}
+		return drf[i].Name < drf[j].Name
+	})
+}
+
+// dateBound converts a date range bound into a comparable value
+func dateBound(bound *string) *float64 {
+	if bound == nil {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, *bound)
+	if err != nil {
+		return nil
+	}
+	rv := float64(t.UnixNano())
+	return &rv
+}
+
+// NumericStats summarizes the values of a numeric field,
+// Min, Max and Avg are zero when no values were seen.
+type NumericStats struct {
+	Count int     `json:"count"`
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+	Sum   float64 `json:"sum"`
+	Avg   float64 `json:"avg"`
+}
+
+// Add includes the value in the stats.
+func (ns *NumericStats) Add(val float64) {
+	if ns.Count == 0 || val < ns.Min {
+		ns.Min = val
+	}
+	if ns.Count == 0 || val > ns.Max {
+		ns.Max = val
+	}
+	ns.Count++
+	ns.Sum += val
+	ns.Avg = ns.Sum / float64(ns.Count)
+}
+
+// Merge combines the stats with stats computed over other documents.
+func (ns *NumericStats) Merge(other *NumericStats) {
+	if other.Count == 0 {
+		return
+	}
+	if ns.Count == 0 || other.Min < ns.Min {
+		ns.Min = other.Min
+	}
+	if ns.Count == 0 || other.Max > ns.Max {
+		ns.Max = other.Max
+	}
+	ns.Count += other.Count
+	ns.Sum += other.Sum
+	ns.Avg = ns.Sum / float64(ns.Count)
+}
+
+// HistogramBucket counts the values of a numeric field falling
+// in [Min, Max).
+type HistogramBucket struct {
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+	Count int     `json:"count"`
+}
+
+// HistogramBuckets are ordered by their bounds.
+type HistogramBuckets []*HistogramBucket
+
+// Merge adds the counts of other to the buckets with the same
+// bounds, keeping the buckets ordered.
+func (hb HistogramBuckets) Merge(other HistogramBuckets) HistogramBuckets {
+	rv := make(HistogramBuckets, 0, len(hb)+len(other))
+	i, j := 0, 0
+	for i < len(hb) || j < len(other) {
+		switch {
+		case j >= len(other) || (i < len(hb) && hb[i].Min < other[j].Min):
+			rv = append(rv, hb[i])
+			i++
+		case i >= len(hb) || other[j].Min < hb[i].Min:
+			rv = append(rv, other[j])
+			j++
+		default:
+			hb[i].Count += other[j].Count
+			rv = append(rv, hb[i])
+			i++
+			j++
+		}
+	}
+	return rv
+}
+
+// Trim keeps the size buckets counting the most values, ordered by
+// their bounds, and returns them with the number of values counted
+// by the buckets left out.
+func (hb HistogramBuckets) Trim(size int) (HistogramBuckets, int) {
+	if len(hb) <= size {
+		return hb, 0
+	}
+	rv := make(HistogramBuckets, len(hb))
+	copy(rv, hb)
+	sort.SliceStable(rv, func(i, j int) bool {
+		return rv[i].Count > rv[j].Count
+	})
+	other := 0
+	for _, bucket := range rv[size:] {
+		other += bucket.Count
+	}
+	rv = rv[:size]
+	sort.Slice(rv, func(i, j int) bool {
+		return rv[i].Min < rv[j].Min
+	})
+	return rv, other
+}
+
+// DateHistogramBucket counts the values of a date field falling
+// in [Start, End).
+type DateHistogramBucket struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	Count int       `json:"count"`
+}
+
+// DateHistogramBuckets are ordered by their bounds.
+type DateHistogramBuckets []*DateHistogramBucket
+
+// Merge adds the counts of other to the buckets with the same
+// bounds, keeping the buckets ordered.
+func (dhb DateHistogramBuckets) Merge(other DateHistogramBuckets) DateHistogramBuckets {
+	rv := make(DateHistogramBuckets, 0, len(dhb)+len(other))
+	i, j := 0, 0
+	for i < len(dhb) || j < len(other) {
+		switch {
+		case j >= len(other) || (i < len(dhb) && dhb[i].Start.Before(other[j].Start)):
+			rv = append(rv, dhb[i])
+			i++
+		case i >= len(dhb) || other[j].Start.Before(dhb[i].Start):
+			rv = append(rv, other[j])
+			j++
+		default:
+			dhb[i].Count += other[j].Count
+			rv = append(rv, dhb[i])
+			i++
+			j++
+		}
+	}
+	return rv
+}
+
 type FacetResult struct {
-	Field         string             `json:"field"`
-	Total         int                `json:"total"`
-	Missing       int                `json:"missing"`
-	Other         int                `json:"other"`
-	Terms         *TermFacets        `json:"terms,omitempty"`
-	NumericRanges NumericRangeFacets `json:"numeric_ranges,omitempty"`
-	DateRanges    DateRangeFacets    `json:"date_ranges,omitempty"`
+	Field         string               `json:"field"`
+	Total         int                  `json:"total"`
+	Missing       int                  `json:"missing"`
+	Other         int                  `json:"other"`
+	Terms         *TermFacets          `json:"terms,omitempty"`
+	NumericRanges NumericRangeFacets   `json:"numeric_ranges,omitempty"`
+	DateRanges    DateRangeFacets      `json:"date_ranges,omitempty"`
+	Stats         *NumericStats        `json:"stats,omitempty"`
+	Histogram     HistogramBuckets     `json:"histogram,omitempty"`
+	DateHistogram DateHistogramBuckets `json:"date_histogram,omitempty"`
 }
 
 func (fr *FacetResult) Size() int {
-	return reflectStaticSizeFacetResult + size.SizeOfPtr +
+	sizeInBytes := reflectStaticSizeFacetResult + size.SizeOfPtr +
 		len(fr.Field) +
 		fr.Terms.Len()*(reflectStaticSizeTermFacet+size.SizeOfPtr) +
 		len(fr.NumericRanges)*(reflectStaticSizeNumericRangeFacet+size.SizeOfPtr) +
-		len(fr.DateRanges)*(reflectStaticSizeDateRangeFacet+size.SizeOfPtr)
+		len(fr.DateRanges)*(reflectStaticSizeDateRangeFacet+size.SizeOfPtr) +
+		len(fr.Histogram)*(reflectStaticSizeHistogramBucket+size.SizeOfPtr) +
+		len(fr.DateHistogram)*(reflectStaticSizeDateHistogramBucket+size.SizeOfPtr)
+
+	if fr.Stats != nil {
+		sizeInBytes += reflectStaticSizeNumericStats
+	}
+
+	return sizeInBytes
 }
 
 func (fr *FacetResult) Merge(other *FacetResult) {
 	fr.Total += other.Total
 	fr.Missing += other.Missing
 	fr.Other += other.Other
+	if other.Stats != nil {
+		if fr.Stats == nil {
+			fr.Stats = other.Stats
+			return
+		}
+		fr.Stats.Merge(other.Stats)
+	}
+	if other.Histogram != nil {
+		fr.Histogram = fr.Histogram.Merge(other.Histogram)
+		return
+	}
+	if other.DateHistogram != nil {
+		fr.DateHistogram = fr.DateHistogram.Merge(other.DateHistogram)
+		return
+	}
 	if other.Terms != nil {
 		if fr.Terms == nil {
 			fr.Terms = other.Terms
@@ -378,6 +612,21 @@ func (fr *FacetResult) Fixup(size int) {
 			}
 			fr.DateRanges = fr.DateRanges[0:size]
 		}
+	} else if fr.Histogram != nil && size > 0 {
+		var other int
+		fr.Histogram, other = fr.Histogram.Trim(size)
+		fr.Other += other
+	}
+}
+
+// SortRangesByBound orders the numeric or date ranges of the
+// result by their bounds, rather than by their count.
+func (fr *FacetResult) SortRangesByBound() {
+	if fr.NumericRanges != nil {
+		fr.NumericRanges.SortByBound()
+	}
+	if fr.DateRanges != nil {
+		fr.DateRanges.SortByBound()
 	}
 }
 
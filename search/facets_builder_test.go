@@ -422,3 +422,12 @@ func TestDateFacetResultsMerge(t *testing.T) {
 		t.Errorf("expected %#v, got %#v", expectedFrs, frs1)
 	}
 }
+
+func TestFacetResultSizeStats(t *testing.T) {
+	fr := &FacetResult{Field: "price"}
+	withoutStats := fr.Size()
+	fr.Stats = &NumericStats{Count: 1, Min: 5, Max: 5, Sum: 5, Avg: 5}
+	if fr.Size() != withoutStats+reflectStaticSizeNumericStats {
+		t.Errorf("expected the stats to be sized only when present, got %d and %d", withoutStats, fr.Size())
+	}
+}
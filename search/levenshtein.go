@@ -112,3 +112,56 @@ func LevenshteinDistanceMaxReuseSlice(a, b string, max int, d []int) (int, bool,
 	}
 	return d[la], false, d
 }
+
+// DamerauLevenshteinDistanceMax is like LevenshteinDistanceMax,
+// but also counts the transposition of two adjacent characters
+// as a single edit (the optimal string alignment distance).
+func DamerauLevenshteinDistanceMax(a, b string, max int) (int, bool) {
+	la := len(a)
+	lb := len(b)
+
+	ld := int(math.Abs(float64(la - lb)))
+	if ld > max {
+		return max, true
+	}
+
+	// rows i-2, i-1 and i of the distance matrix
+	prev2 := make([]int, la+1)
+	prev := make([]int, la+1)
+	cur := make([]int, la+1)
+
+	for j := 0; j <= la; j++ {
+		prev[j] = j
+	}
+	for i := 1; i <= lb; i++ {
+		cur[0] = i
+		rowmin := cur[0]
+		for j := 1; j <= la; j++ {
+			cost := 1
+			if a[j-1] == b[i-1] {
+				cost = 0
+			}
+			min := prev[j] + 1
+			if cur[j-1]+1 < min {
+				min = cur[j-1] + 1
+			}
+			if prev[j-1]+cost < min {
+				min = prev[j-1] + cost
+			}
+			if i > 1 && j > 1 && a[j-1] == b[i-2] && a[j-2] == b[i-1] &&
+				prev2[j-2]+1 < min {
+				min = prev2[j-2] + 1
+			}
+			if min < rowmin {
+				rowmin = min
+			}
+			cur[j] = min
+		}
+		// after each row if rowmin isn't less than max stop
+		if rowmin > max {
+			return max, true
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return prev[la], false
+}
@@ -85,6 +85,53 @@ func TestLevenshteinDistanceMax(t *testing.T) {
 	}
 }
 
+func TestDamerauLevenshteinDistanceMax(t *testing.T) {
+
+	tests := []struct {
+		a        string
+		b        string
+		max      int
+		dist     int
+		exceeded bool
+	}{
+		{
+			a:        "teh",
+			b:        "the",
+			max:      1,
+			dist:     1,
+			exceeded: false,
+		},
+		{
+			a:        "ca",
+			b:        "abc",
+			max:      3,
+			dist:     3,
+			exceeded: false,
+		},
+		{
+			a:        "water",
+			b:        "awtre",
+			max:      2,
+			dist:     2,
+			exceeded: false,
+		},
+		{
+			a:        "water",
+			b:        "christmas",
+			max:      3,
+			dist:     3,
+			exceeded: true,
+		},
+	}
+
+	for _, test := range tests {
+		actual, exceeded := DamerauLevenshteinDistanceMax(test.a, test.b, test.max)
+		if actual != test.dist || exceeded != test.exceeded {
+			t.Errorf("expected %d %t, got %d %t for %s and %s", test.dist, test.exceeded, actual, exceeded, test.a, test.b)
+		}
+	}
+}
+
 // 5 terms that are less than 2
 // 5 terms that are more than 2
 var benchmarkTerms = []string{
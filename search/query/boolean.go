@@ -69,7 +69,17 @@ func NewBooleanQueryForQueryString(must []Query, should []Query, mustNot []Query
 
 // SetMinShould requires that at least minShould of the
 // should Queries must be satisfied.
+// With a minShould of 0, the default, the should Queries only
+// boost the score of the documents satisfying the must Queries,
+// they do not filter them.  A query with only should Queries,
+// and possibly must not Queries, still requires documents to
+// satisfy at least one of them.
 func (q *BooleanQuery) SetMinShould(minShould float64) {
+	if q.Should == nil {
+		tmp := NewDisjunctionQuery([]Query{})
+		tmp.queryStringMode = q.queryStringMode
+		q.Should = tmp
+	}
 	q.Should.(*DisjunctionQuery).SetMin(minShould)
 }
 
@@ -208,6 +218,16 @@ func (q *BooleanQuery) Validate() error {
 	return nil
 }
 
+func (q *BooleanQuery) walkChildren(visit func(name string, child *Query) error) error {
+	if err := visit("must", &q.Must); err != nil {
+		return err
+	}
+	if err := visit("should", &q.Should); err != nil {
+		return err
+	}
+	return visit("must_not", &q.MustNot)
+}
+
 func (q *BooleanQuery) UnmarshalJSON(data []byte) error {
 	tmp := struct {
 		Must    json.RawMessage `json:"must,omitempty"`
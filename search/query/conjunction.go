@@ -92,6 +92,10 @@ func (q *ConjunctionQuery) Validate() error {
 	return nil
 }
 
+func (q *ConjunctionQuery) walkChildren(visit func(name string, child *Query) error) error {
+	return walkChildSlice("conjuncts", q.Conjuncts, visit)
+}
+
 func (q *ConjunctionQuery) UnmarshalJSON(data []byte) error {
 	tmp := struct {
 		Conjuncts []json.RawMessage `json:"conjuncts"`
@@ -0,0 +1,98 @@
+//  Copyright (c) 2024 Couchbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 		http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package query
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/blevesearch/bleve/v2/mapping"
+	"github.com/blevesearch/bleve/v2/search"
+	"github.com/blevesearch/bleve/v2/search/searcher"
+	"github.com/blevesearch/bleve/v2/util"
+	index "github.com/blevesearch/bleve_index_api"
+)
+
+type ConstantScoreQuery struct {
+	Query    Query  `json:"constant_score"`
+	BoostVal *Boost `json:"boost,omitempty"`
+}
+
+// NewConstantScoreQuery creates a new Query which
+// matches the same documents as the wrapped query,
+// but gives every one of them the same score,
+// determined only by the boost.
+func NewConstantScoreQuery(q Query) *ConstantScoreQuery {
+	return &ConstantScoreQuery{
+		Query: q,
+	}
+}
+
+func (q *ConstantScoreQuery) SetBoost(b float64) {
+	boost := Boost(b)
+	q.BoostVal = &boost
+}
+
+func (q *ConstantScoreQuery) Boost() float64 {
+	return q.BoostVal.Value()
+}
+
+func (q *ConstantScoreQuery) Searcher(ctx context.Context, i index.IndexReader, m mapping.IndexMapping, options search.SearcherOptions) (search.Searcher, error) {
+	// the scores of the wrapped query are discarded,
+	// so there is no point in computing them
+	childOptions := options
+	childOptions.Explain = false
+	childOptions.Score = "none"
+	s, err := q.Query.Searcher(ctx, i, m, childOptions)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := s.(*searcher.MatchNoneSearcher); ok {
+		return s, nil
+	}
+	return searcher.NewConstantScoreSearcher(ctx, s, q.BoostVal.Value(), options), nil
+}
+
+func (q *ConstantScoreQuery) Validate() error {
+	if q.Query == nil {
+		return fmt.Errorf("constant score query must wrap a query")
+	}
+	if vq, ok := q.Query.(ValidatableQuery); ok {
+		return vq.Validate()
+	}
+	return nil
+}
+
+func (q *ConstantScoreQuery) walkChildren(visit func(name string, child *Query) error) error {
+	return visit("constant_score", &q.Query)
+}
+
+func (q *ConstantScoreQuery) UnmarshalJSON(data []byte) error {
+	tmp := struct {
+		Query json.RawMessage `json:"constant_score"`
+		Boost *Boost          `json:"boost,omitempty"`
+	}{}
+	err := util.UnmarshalJSON(data, &tmp)
+	if err != nil {
+		return err
+	}
+	q.Query, err = ParseQuery(tmp.Query)
+	if err != nil {
+		return err
+	}
+	q.BoostVal = tmp.Boost
+	return nil
+}
@@ -111,6 +111,10 @@ func (q *DisjunctionQuery) Validate() error {
 	return nil
 }
 
+func (q *DisjunctionQuery) walkChildren(visit func(name string, child *Query) error) error {
+	return walkChildSlice("disjuncts", q.Disjuncts, visit)
+}
+
 func (q *DisjunctionQuery) UnmarshalJSON(data []byte) error {
 	tmp := struct {
 		Disjuncts []json.RawMessage `json:"disjuncts"`
@@ -30,7 +30,10 @@ type FuzzyQuery struct {
 	Fuzziness int    `json:"fuzziness"`
 	FieldVal  string `json:"field,omitempty"`
 	BoostVal  *Boost `json:"boost,omitempty"`
-	autoFuzzy bool
+	// Transpositions counts swapping two adjacent characters
+	// as a single edit, rather than two.
+	Transpositions bool `json:"transpositions,omitempty"`
+	autoFuzzy      bool
 }
 
 // NewFuzzyQuery creates a new Query which finds
@@ -72,6 +75,10 @@ func (q *FuzzyQuery) SetAutoFuzziness(a bool) {
 	q.autoFuzzy = a
 }
 
+func (q *FuzzyQuery) SetTranspositions(t bool) {
+	q.Transpositions = t
+}
+
 func (q *FuzzyQuery) SetPrefix(p int) {
 	q.Prefix = p
 }
@@ -81,6 +88,12 @@ func (q *FuzzyQuery) Searcher(ctx context.Context, i index.IndexReader, m mappin
 	if q.FieldVal == "" {
 		field = m.DefaultSearchField()
 	}
+	if q.Transpositions {
+		if q.autoFuzzy {
+			return searcher.NewAutoTranspositionFuzzySearcher(ctx, i, q.Term, q.Prefix, field, q.BoostVal.Value(), options)
+		}
+		return searcher.NewTranspositionFuzzySearcher(ctx, i, q.Term, q.Prefix, q.Fuzziness, field, q.BoostVal.Value(), options)
+	}
 	if q.autoFuzzy {
 		return searcher.NewAutoFuzzySearcher(ctx, i, q.Term, q.Prefix, field, q.BoostVal.Value(), options)
 	}
@@ -122,6 +135,8 @@ func (f *FuzzyQuery) MarshalJSON() ([]byte, error) {
 		Fuzziness interface{} `json:"fuzziness"`
 		FieldVal  string      `json:"field,omitempty"`
 		BoostVal  *Boost      `json:"boost,omitempty"`
+
+		Transpositions bool `json:"transpositions,omitempty"`
 	}
 	aux := fuzzyQuery{
 		Term:      f.Term,
@@ -129,6 +144,8 @@ func (f *FuzzyQuery) MarshalJSON() ([]byte, error) {
 		Fuzziness: fuzzyValue,
 		FieldVal:  f.FieldVal,
 		BoostVal:  f.BoostVal,
+
+		Transpositions: f.Transpositions,
 	}
 	return util.MarshalJSON(aux)
 }
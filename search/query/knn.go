@@ -96,3 +96,7 @@ func (q *KNNQuery) Searcher(ctx context.Context, i index.IndexReader,
 		q.Vector, q.K, q.BoostVal.Value(), similarityMetric, q.Params,
 		q.filterResults)
 }
+
+func (q *KNNQuery) walkChildren(visit func(name string, child *Query) error) error {
+	return visit("filter", &q.FilterQuery)
+}
@@ -18,6 +18,7 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/blevesearch/bleve/v2/analysis"
 	"github.com/blevesearch/bleve/v2/mapping"
 	"github.com/blevesearch/bleve/v2/search"
 	"github.com/blevesearch/bleve/v2/util"
@@ -32,7 +33,17 @@ type MatchQuery struct {
 	Prefix    int                `json:"prefix_length"`
 	Fuzziness int                `json:"fuzziness"`
 	Operator  MatchQueryOperator `json:"operator,omitempty"`
-	autoFuzzy bool
+	// CutoffFrequency, when set, splits the analyzed terms into
+	// rare and common ones based on their document frequency.
+	// Values below 1 are a fraction of the documents in the
+	// index, values of 1 or more are an absolute document count.
+	// Only the rare terms are subject to the operator, common
+	// terms can only improve the score of a matching document.
+	CutoffFrequency float64 `json:"cutoff_frequency,omitempty"`
+	// Transpositions makes the fuzzy matching of the terms count
+	// swapping two adjacent characters as a single edit.
+	Transpositions bool `json:"transpositions,omitempty"`
+	autoFuzzy      bool
 }
 
 type MatchQueryOperator int
@@ -112,6 +123,10 @@ func (q *MatchQuery) SetAutoFuzziness(auto bool) {
 	q.autoFuzzy = auto
 }
 
+func (q *MatchQuery) SetTranspositions(t bool) {
+	q.Transpositions = t
+}
+
 func (q *MatchQuery) SetPrefix(p int) {
 	q.Prefix = p
 }
@@ -120,6 +135,10 @@ func (q *MatchQuery) SetOperator(operator MatchQueryOperator) {
 	q.Operator = operator
 }
 
+func (q *MatchQuery) SetCutoffFrequency(cutoff float64) {
+	q.CutoffFrequency = cutoff
+}
+
 func (q *MatchQuery) Searcher(ctx context.Context, i index.IndexReader, m mapping.IndexMapping, options search.SearcherOptions) (search.Searcher, error) {
 
 	field := q.FieldVal
@@ -133,6 +152,7 @@ func (q *MatchQuery) Searcher(ctx context.Context, i index.IndexReader, m mappin
 	} else {
 		analyzerName = m.AnalyzerNameForPath(field)
 	}
+	warnAnalyzerMismatch(ctx, m, field, q.Analyzer)
 	analyzer := m.AnalyzerNamed(analyzerName)
 
 	if analyzer == nil {
@@ -151,6 +171,7 @@ func (q *MatchQuery) Searcher(ctx context.Context, i index.IndexReader, m mappin
 				} else {
 					query.SetFuzziness(q.Fuzziness)
 				}
+				query.SetTranspositions(q.Transpositions)
 				query.SetPrefix(q.Prefix)
 				query.SetField(field)
 				query.SetBoost(q.BoostVal.Value())
@@ -165,6 +186,32 @@ func (q *MatchQuery) Searcher(ctx context.Context, i index.IndexReader, m mappin
 			}
 		}
 
+		if q.CutoffFrequency > 0 {
+			rare, common, err := q.splitByFrequency(ctx, i, field, tokens, tqs)
+			if err != nil {
+				return nil, err
+			}
+			// only when some terms are common and others are rare
+			// does the cutoff change anything, if all the terms are
+			// common the operator is applied to them as usual
+			if len(rare) > 0 && len(common) > 0 {
+				var rareQuery Query
+				switch q.Operator {
+				case MatchQueryOperatorOr:
+					disjunction := NewDisjunctionQuery(rare)
+					disjunction.SetMin(1)
+					rareQuery = disjunction
+				case MatchQueryOperatorAnd:
+					rareQuery = NewConjunctionQuery(rare)
+				default:
+					return nil, fmt.Errorf("unhandled operator %d", q.Operator)
+				}
+				boolQuery := NewBooleanQuery([]Query{rareQuery}, common, nil)
+				boolQuery.SetBoost(q.BoostVal.Value())
+				return boolQuery.Searcher(ctx, i, m, options)
+			}
+		}
+
 		switch q.Operator {
 		case MatchQueryOperatorOr:
 			shouldQuery := NewDisjunctionQuery(tqs)
@@ -185,6 +232,38 @@ func (q *MatchQuery) Searcher(ctx context.Context, i index.IndexReader, m mappin
 	return noneQuery.Searcher(ctx, i, m, options)
 }
 
+// splitByFrequency partitions the term queries built from the tokens
+// into those for rare terms and those for terms whose document
+// frequency in the field exceeds the cutoff frequency.
+func (q *MatchQuery) splitByFrequency(ctx context.Context, i index.IndexReader,
+	field string, tokens analysis.TokenStream, tqs []Query) (rare, common []Query, err error) {
+	cutoff := q.CutoffFrequency
+	if cutoff < 1 {
+		docCount, err := i.DocCount()
+		if err != nil {
+			return nil, nil, err
+		}
+		cutoff *= float64(docCount)
+	}
+
+	for idx, token := range tokens {
+		tfr, err := i.TermFieldReader(ctx, token.Term, field, false, false, false)
+		if err != nil {
+			return nil, nil, err
+		}
+		count := tfr.Count()
+		if err := tfr.Close(); err != nil {
+			return nil, nil, err
+		}
+		if float64(count) > cutoff {
+			common = append(common, tqs[idx])
+		} else {
+			rare = append(rare, tqs[idx])
+		}
+	}
+	return rare, common, nil
+}
+
 func (q *MatchQuery) UnmarshalJSON(data []byte) error {
 	type Alias MatchQuery
 	aux := &struct {
@@ -222,6 +301,9 @@ func (f *MatchQuery) MarshalJSON() ([]byte, error) {
 		Prefix    int                `json:"prefix_length"`
 		Fuzziness interface{}        `json:"fuzziness"`
 		Operator  MatchQueryOperator `json:"operator,omitempty"`
+
+		CutoffFrequency float64 `json:"cutoff_frequency,omitempty"`
+		Transpositions  bool    `json:"transpositions,omitempty"`
 	}
 	aux := match{
 		Match:     f.Match,
@@ -231,6 +313,9 @@ func (f *MatchQuery) MarshalJSON() ([]byte, error) {
 		Prefix:    f.Prefix,
 		Fuzziness: fuzzyValue,
 		Operator:  f.Operator,
+
+		CutoffFrequency: f.CutoffFrequency,
+		Transpositions:  f.Transpositions,
 	}
 	return util.MarshalJSON(aux)
 }
@@ -85,6 +85,7 @@ func (q *MatchPhraseQuery) Searcher(ctx context.Context, i index.IndexReader, m
 	} else {
 		analyzerName = m.AnalyzerNameForPath(field)
 	}
+	warnAnalyzerMismatch(ctx, m, field, q.Analyzer)
 	analyzer := m.AnalyzerNamed(analyzerName)
 	if analyzer == nil {
 		return nil, fmt.Errorf("no analyzer named '%s' registered", q.Analyzer)
@@ -0,0 +1,154 @@
+//  Copyright (c) 2024 Couchbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 		http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package query
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/blevesearch/bleve/v2/mapping"
+	"github.com/blevesearch/bleve/v2/search"
+	"github.com/blevesearch/bleve/v2/search/searcher"
+	index "github.com/blevesearch/bleve_index_api"
+)
+
+// DefaultMatchPhrasePrefixMaxExpansions is the number of terms the
+// final prefix of a MatchPhrasePrefixQuery expands to, when no
+// explicit limit has been set on the query.
+var DefaultMatchPhrasePrefixMaxExpansions = 50
+
+type MatchPhrasePrefixQuery struct {
+	MatchPhrasePrefix string `json:"match_phrase_prefix"`
+	FieldVal          string `json:"field,omitempty"`
+	Analyzer          string `json:"analyzer,omitempty"`
+	BoostVal          *Boost `json:"boost,omitempty"`
+	MaxExpansions     int    `json:"max_expansions,omitempty"`
+}
+
+// NewMatchPhrasePrefixQuery creates a new Query object
+// for matching phrases in the index, where the last
+// term of the phrase is treated as a prefix.
+// An Analyzer is chosen based on the field.
+// Input text is analyzed using this analyzer.
+// Token terms resulting from this analysis are
+// used to build a search phrase, with the final
+// position satisfied by any indexed term starting
+// with the final token.  Queried field must have been
+// indexed with IncludeTermVectors set to true.
+func NewMatchPhrasePrefixQuery(matchPhrasePrefix string) *MatchPhrasePrefixQuery {
+	return &MatchPhrasePrefixQuery{
+		MatchPhrasePrefix: matchPhrasePrefix,
+	}
+}
+
+func (q *MatchPhrasePrefixQuery) SetBoost(b float64) {
+	boost := Boost(b)
+	q.BoostVal = &boost
+}
+
+func (q *MatchPhrasePrefixQuery) Boost() float64 {
+	return q.BoostVal.Value()
+}
+
+func (q *MatchPhrasePrefixQuery) SetField(f string) {
+	q.FieldVal = f
+}
+
+func (q *MatchPhrasePrefixQuery) Field() string {
+	return q.FieldVal
+}
+
+// SetMaxExpansions limits the number of indexed terms
+// the final prefix may expand to.
+func (q *MatchPhrasePrefixQuery) SetMaxExpansions(n int) {
+	q.MaxExpansions = n
+}
+
+func (q *MatchPhrasePrefixQuery) Searcher(ctx context.Context, i index.IndexReader, m mapping.IndexMapping, options search.SearcherOptions) (search.Searcher, error) {
+	field := q.FieldVal
+	if q.FieldVal == "" {
+		field = m.DefaultSearchField()
+	}
+
+	analyzerName := ""
+	if q.Analyzer != "" {
+		analyzerName = q.Analyzer
+	} else {
+		analyzerName = m.AnalyzerNameForPath(field)
+	}
+	analyzer := m.AnalyzerNamed(analyzerName)
+	if analyzer == nil {
+		return nil, fmt.Errorf("no analyzer named '%s' registered", analyzerName)
+	}
+
+	tokens := analyzer.Analyze([]byte(q.MatchPhrasePrefix))
+	if len(tokens) > 0 {
+		phrase := tokenStreamToPhrase(tokens)
+		last := len(phrase) - 1
+		expansions, err := q.expandPrefixes(ctx, i, field, phrase[last])
+		if err != nil {
+			return nil, err
+		}
+		if len(expansions) > 0 {
+			phrase[last] = expansions
+			return searcher.NewMultiPhraseSearcher(ctx, i, phrase, 0, false, field, q.BoostVal.Value(), options)
+		}
+	}
+	noneQuery := NewMatchNoneQuery()
+	return noneQuery.Searcher(ctx, i, m, options)
+}
+
+// expandPrefixes returns the indexed terms of the field starting
+// with any of the given prefixes, up to the configured limit.
+func (q *MatchPhrasePrefixQuery) expandPrefixes(ctx context.Context, i index.IndexReader,
+	field string, prefixes []string) (rv []string, err error) {
+	maxExpansions := q.MaxExpansions
+	if maxExpansions <= 0 {
+		maxExpansions = DefaultMatchPhrasePrefixMaxExpansions
+	}
+
+	seen := make(map[string]struct{})
+	for _, prefix := range prefixes {
+		fieldDict, err := i.FieldDictPrefix(field, []byte(prefix))
+		if err != nil {
+			return nil, err
+		}
+		tfd, err := fieldDict.Next()
+		for err == nil && tfd != nil && len(rv) < maxExpansions {
+			if _, exists := seen[tfd.Term]; !exists {
+				seen[tfd.Term] = struct{}{}
+				rv = append(rv, tfd.Term)
+			}
+			tfd, err = fieldDict.Next()
+		}
+		if ctx != nil {
+			search.RecordSearchCost(ctx, search.AddM, fieldDict.BytesRead())
+		}
+		if cerr := fieldDict.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return rv, nil
+}
+
+func (q *MatchPhrasePrefixQuery) Validate() error {
+	if q.MaxExpansions < 0 {
+		return fmt.Errorf("match phrase prefix query max expansions must not be negative")
+	}
+	return nil
+}
@@ -142,6 +142,15 @@ func ParseQuery(input []byte) (Query, error) {
 		}
 		return &rv, nil
 	}
+	_, isMatchPhrasePrefixQuery := tmp["match_phrase_prefix"]
+	if isMatchPhrasePrefixQuery {
+		var rv MatchPhrasePrefixQuery
+		err := util.UnmarshalJSON(input, &rv)
+		if err != nil {
+			return nil, err
+		}
+		return &rv, nil
+	}
 	if hasTerms {
 		var rv PhraseQuery
 		err := util.UnmarshalJSON(input, &rv)
@@ -195,6 +204,16 @@ func ParseQuery(input []byte) (Query, error) {
 		return &rv, nil
 	}
 
+	_, hasConstantScore := tmp["constant_score"]
+	if hasConstantScore {
+		var rv ConstantScoreQuery
+		err := util.UnmarshalJSON(input, &rv)
+		if err != nil {
+			return nil, err
+		}
+		return &rv, nil
+	}
+
 	_, hasSyntaxQuery := tmp["query"]
 	if hasSyntaxQuery {
 		var rv QueryStringQuery
@@ -371,7 +390,7 @@ func expandQuery(m mapping.IndexMapping, query Query) (Query, error) {
 	expand = func(query Query) (Query, error) {
 		switch q := query.(type) {
 		case *QueryStringQuery:
-			parsed, err := parseQuerySyntax(q.Query)
+			parsed, err := q.Parse()
 			if err != nil {
 				return nil, fmt.Errorf("could not parse '%s': %s", q.Query, err)
 			}
@@ -405,6 +424,13 @@ func expandQuery(m mapping.IndexMapping, query Query) (Query, error) {
 				return nil, err
 			}
 			return q, nil
+		case *ConstantScoreQuery:
+			var err error
+			q.Query, err = expand(q.Query)
+			if err != nil {
+				return nil, err
+			}
+			return q, nil
 		default:
 			return query, nil
 		}
@@ -423,3 +449,93 @@ func DumpQuery(m mapping.IndexMapping, query Query) (string, error) {
 	data, err := json.MarshalIndent(q, "", "  ")
 	return string(data), err
 }
+
+// warnAnalyzerMismatch records a search warning when the query forces
+// an analyzer other than the one its field is indexed with, as the
+// terms it searches for may then never match the indexed ones.
+func warnAnalyzerMismatch(ctx context.Context, m mapping.IndexMapping, field, analyzer string) {
+	if analyzer == "" || ctx == nil || ctx.Value(search.SearchWarningCallbackKey) == nil {
+		return
+	}
+	if indexed := m.AnalyzerNameForPath(field); indexed != analyzer {
+		search.RecordSearchWarning(ctx, fmt.Sprintf("field '%s' is indexed with "+
+			"analyzer '%s' but queried with analyzer '%s'", field, indexed, analyzer))
+	}
+}
+
+// compoundQuery is implemented by the queries composed of other
+// queries, walkChildren calls visit with the name and the address
+// of each of their children.
+type compoundQuery interface {
+	walkChildren(visit func(name string, child *Query) error) error
+}
+
+// IsCompound returns whether the query is composed of other queries,
+// whose children WalkChildren visits.
+func IsCompound(q Query) bool {
+	_, ok := q.(compoundQuery)
+	return ok
+}
+
+// WalkChildren calls fn with each query directly composing q, along
+// with the name locating it within q, for example "must" or
+// "conjuncts[1]": the clauses of boolean, conjunction and disjunction
+// queries, the query of a constant score query and the filter of a kNN
+// query.  A child is replaced by the query fn returns for it when they
+// differ.  Query string queries have no children until parsed.  The
+// walk stops at the first error returned by fn, which is returned.
+func WalkChildren(q Query, fn func(name string, child Query) (Query, error)) error {
+	cq, ok := q.(compoundQuery)
+	if !ok {
+		return nil
+	}
+	return cq.walkChildren(func(name string, child *Query) error {
+		if *child == nil {
+			return nil
+		}
+		rv, err := fn(name, *child)
+		if err != nil {
+			return err
+		}
+		if rv != *child {
+			*child = rv
+		}
+		return nil
+	})
+}
+
+func walkChildSlice(name string, children []Query, visit func(name string, child *Query) error) error {
+	for i := range children {
+		err := visit(fmt.Sprintf("%s[%d]", name, i), &children[i])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CountClauses returns the number of leaf queries in the query tree,
+// the queries which are not composed of other queries.  Query string
+// queries are parsed and their clauses counted, a query string which
+// does not parse counts as a single clause.
+func CountClauses(query Query) int {
+	switch q := query.(type) {
+	case nil:
+		return 0
+	case *QueryStringQuery:
+		parsed, err := q.Parse()
+		if err != nil {
+			return 1
+		}
+		return CountClauses(parsed)
+	}
+	if !IsCompound(query) {
+		return 1
+	}
+	rv := 0
+	_ = WalkChildren(query, func(_ string, child Query) (Query, error) {
+		rv += CountClauses(child)
+		return child, nil
+	})
+	return rv
+}
@@ -16,6 +16,9 @@ package query
 
 import (
 	"context"
+	"fmt"
+	"reflect"
+	"sort"
 
 	"github.com/blevesearch/bleve/v2/mapping"
 	"github.com/blevesearch/bleve/v2/search"
@@ -25,6 +28,14 @@ import (
 type QueryStringQuery struct {
 	Query    string `json:"query"`
 	BoostVal *Boost `json:"boost,omitempty"`
+	// FieldBoosts multiplies the boost of every clause targeting
+	// one of its fields.  When set, clauses which do not name a
+	// field are searched across exactly these fields instead of
+	// the default search field, each with its own boost.
+	FieldBoosts map[string]float64 `json:"field_boosts,omitempty"`
+	// Analyzer overrides the analyzer the mapping would choose
+	// for the field of every clause analyzing its input.
+	Analyzer string `json:"analyzer,omitempty"`
 }
 
 // NewQueryStringQuery creates a new Query used for
@@ -45,12 +56,32 @@ func (q *QueryStringQuery) Boost() float64 {
 	return q.BoostVal.Value()
 }
 
+// SetFieldBoost sets the boost applied to clauses targeting the field.
+func (q *QueryStringQuery) SetFieldBoost(field string, b float64) {
+	if q.FieldBoosts == nil {
+		q.FieldBoosts = make(map[string]float64)
+	}
+	q.FieldBoosts[field] = b
+}
+
+// SetAnalyzer overrides the analyzer used for the clauses of the query.
+func (q *QueryStringQuery) SetAnalyzer(analyzer string) {
+	q.Analyzer = analyzer
+}
+
 func (q *QueryStringQuery) Parse() (Query, error) {
-	return parseQuerySyntax(q.Query)
+	newQuery, err := parseQuerySyntax(q.Query)
+	if err != nil {
+		return nil, err
+	}
+	return q.rewrite(newQuery), nil
 }
 
 func (q *QueryStringQuery) Searcher(ctx context.Context, i index.IndexReader, m mapping.IndexMapping, options search.SearcherOptions) (search.Searcher, error) {
-	newQuery, err := parseQuerySyntax(q.Query)
+	if q.Analyzer != "" && m.AnalyzerNamed(q.Analyzer) == nil {
+		return nil, fmt.Errorf("no analyzer named '%s' registered", q.Analyzer)
+	}
+	newQuery, err := q.Parse()
 	if err != nil {
 		return nil, err
 	}
@@ -58,7 +89,15 @@ func (q *QueryStringQuery) Searcher(ctx context.Context, i index.IndexReader, m
 }
 
 func (q *QueryStringQuery) Validate() error {
-	newQuery, err := parseQuerySyntax(q.Query)
+	for field, boost := range q.FieldBoosts {
+		if field == "" {
+			return fmt.Errorf("query string field boosts cannot contain an empty field name")
+		}
+		if boost <= 0 {
+			return fmt.Errorf("query string field boost for '%s' must be positive", field)
+		}
+	}
+	newQuery, err := q.Parse()
 	if err != nil {
 		return err
 	}
@@ -67,3 +106,91 @@ func (q *QueryStringQuery) Validate() error {
 	}
 	return nil
 }
+
+// rewrite applies the options of the query string query to the
+// clauses produced by the query string parser.
+func (q *QueryStringQuery) rewrite(parsed Query) Query {
+	if q.Analyzer != "" {
+		parsed = rewriteQueryStringClauses(parsed, q.applyAnalyzer)
+	}
+	if len(q.FieldBoosts) > 0 {
+		parsed = rewriteQueryStringClauses(parsed, q.applyFieldBoosts)
+	}
+	return parsed
+}
+
+// applyAnalyzer sets the analyzer of the clauses analyzing their input.
+func (q *QueryStringQuery) applyAnalyzer(clause Query) Query {
+	switch clause := clause.(type) {
+	case *MatchQuery:
+		clause.Analyzer = q.Analyzer
+	case *MatchPhraseQuery:
+		clause.Analyzer = q.Analyzer
+	}
+	return clause
+}
+
+// applyFieldBoosts multiplies the boost of a clause targeting a boosted
+// field, and expands a clause without a field into a disjunction over
+// all the boosted fields.
+func (q *QueryStringQuery) applyFieldBoosts(clause Query) Query {
+	fq, ok := clause.(FieldableQuery)
+	if !ok {
+		return clause
+	}
+	if fq.Field() != "" {
+		if boost, ok := q.FieldBoosts[fq.Field()]; ok {
+			multiplyBoost(fq, boost)
+		}
+		return fq
+	}
+
+	fields := make([]string, 0, len(q.FieldBoosts))
+	for field := range q.FieldBoosts {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	disjuncts := make([]Query, 0, len(fields))
+	for _, field := range fields {
+		fieldQuery := copyFieldableQuery(fq)
+		fieldQuery.SetField(field)
+		multiplyBoost(fieldQuery, q.FieldBoosts[field])
+		disjuncts = append(disjuncts, fieldQuery)
+	}
+	rv := NewDisjunctionQuery(disjuncts)
+	rv.queryStringMode = true
+	return rv
+}
+
+// rewriteQueryStringClauses walks the compound queries built by the
+// query string parser, replacing every other clause with the result
+// of the rewrite function.
+func rewriteQueryStringClauses(query Query, rewrite func(Query) Query) Query {
+	if !IsCompound(query) {
+		return rewrite(query)
+	}
+	_ = WalkChildren(query, func(_ string, child Query) (Query, error) {
+		return rewriteQueryStringClauses(child, rewrite), nil
+	})
+	return query
+}
+
+func multiplyBoost(q Query, boost float64) {
+	if bq, ok := q.(BoostableQuery); ok {
+		bq.SetBoost(bq.Boost() * boost)
+	}
+}
+
+// copyFieldableQuery returns a shallow copy of the query, the
+// clauses built by the query string parser hold no shared state
+// which would be modified through the copy.
+func copyFieldableQuery(q FieldableQuery) FieldableQuery {
+	v := reflect.ValueOf(q)
+	if v.Kind() != reflect.Ptr {
+		return q
+	}
+	rv := reflect.New(v.Elem().Type())
+	rv.Elem().Set(v.Elem())
+	return rv.Interface().(FieldableQuery)
+}
@@ -113,6 +113,25 @@ func TestParseQuery(t *testing.T) {
 				return q
 			}(),
 		},
+		{
+			input: []byte(`{"match":"light beer","field":"desc","cutoff_frequency":0.01}`),
+			output: func() Query {
+				q := NewMatchQuery("light beer")
+				q.SetField("desc")
+				q.SetCutoffFrequency(0.01)
+				return q
+			}(),
+		},
+		{
+			input: []byte(`{"constant_score":{"match":"beer","field":"desc"},"boost":2}`),
+			output: func() Query {
+				mq := NewMatchQuery("beer")
+				mq.SetField("desc")
+				q := NewConstantScoreQuery(mq)
+				q.SetBoost(2)
+				return q
+			}(),
+		},
 		{
 			input:  []byte(`{"match":"beer","field":"desc","operator":"does not exist"}`),
 			output: nil,
@@ -126,6 +145,14 @@ func TestParseQuery(t *testing.T) {
 				return q
 			}(),
 		},
+		{
+			input: []byte(`{"match_phrase_prefix":"light be","field":"desc"}`),
+			output: func() Query {
+				q := NewMatchPhrasePrefixQuery("light be")
+				q.SetField("desc")
+				return q
+			}(),
+		},
 		{
 			input: []byte(`{"must":{"conjuncts": [{"match":"beer","field":"desc"}]},"should":{"disjuncts": [{"match":"water","field":"desc"}],"min":1.0},"must_not":{"disjuncts": [{"match":"devon","field":"desc"}]}}`),
 			output: func() Query {
@@ -157,6 +184,23 @@ func TestParseQuery(t *testing.T) {
 			input:  []byte(`{"query":"+beer \"light beer\" -devon"}`),
 			output: NewQueryStringQuery(`+beer "light beer" -devon`),
 		},
+		{
+			input: []byte(`{"query":"beer","field_boosts":{"name":3,"desc":1}}`),
+			output: func() Query {
+				q := NewQueryStringQuery(`beer`)
+				q.SetFieldBoost("name", 3)
+				q.SetFieldBoost("desc", 1)
+				return q
+			}(),
+		},
+		{
+			input: []byte(`{"query":"beer","analyzer":"keyword"}`),
+			output: func() Query {
+				q := NewQueryStringQuery(`beer`)
+				q.SetAnalyzer("keyword")
+				return q
+			}(),
+		},
 		{
 			input: []byte(`{"min":5.1,"max":7.1,"field":"desc"}`),
 			output: func() Query {
@@ -785,3 +829,64 @@ func TestParseEmptyQuery(t *testing.T) {
 		t.Errorf("[2] Expected %#v, got %#v", expect, rv)
 	}
 }
+
+func TestWalkChildren(t *testing.T) {
+	a := NewTermQuery("a")
+	b := NewTermQuery("b")
+	c := NewTermQuery("c")
+	q := NewBooleanQuery([]Query{a}, nil, []Query{NewConstantScoreQuery(b)})
+	q.AddShould(c)
+
+	var names []string
+	err := WalkChildren(q, func(name string, child Query) (Query, error) {
+		names = append(names, name)
+		return child, WalkChildren(child, func(name string, child Query) (Query, error) {
+			names = append(names, name)
+			return child, nil
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"must", "conjuncts[0]", "should", "disjuncts[0]", "must_not", "disjuncts[0]"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected children %v, got %v", expected, names)
+	}
+
+	if CountClauses(q) != 3 {
+		t.Errorf("expected 3 clauses, got %d", CountClauses(q))
+	}
+
+	replacement := NewTermQuery("d")
+	err = WalkChildren(q.Must, func(_ string, child Query) (Query, error) {
+		return replacement, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.Must.(*ConjunctionQuery).Conjuncts[0] != replacement {
+		t.Errorf("expected the child to be replaced")
+	}
+}
+
+func TestCheckLeadingWildcards(t *testing.T) {
+	tests := []struct {
+		query Query
+		err   bool
+	}{
+		{query: NewWildcardQuery("abc*")},
+		{query: NewWildcardQuery("*abc"), err: true},
+		{query: NewConjunctionQuery([]Query{NewTermQuery("a"), NewWildcardQuery("?bc")}), err: true},
+		{query: NewConstantScoreQuery(NewRegexpQuery(".*abc")), err: true},
+		{query: NewMatchPhrasePrefixQuery("quick bro")},
+		{query: NewMatchPhrasePrefixQuery("quick *own"), err: true},
+		{query: NewBooleanQuery(nil, nil, []Query{NewMatchPhrasePrefixQuery("?own")}), err: true},
+		{query: NewQueryStringQuery("title:*abc"), err: true},
+	}
+	for i, test := range tests {
+		err := CheckLeadingWildcards(test.query)
+		if (err != nil) != test.err {
+			t.Errorf("%d: expected error %t, got %v", i, test.err, err)
+		}
+	}
+}
@@ -16,6 +16,8 @@ package query
 
 import (
 	"context"
+	"fmt"
+	"regexp"
 	"strings"
 
 	"github.com/blevesearch/bleve/v2/mapping"
@@ -92,3 +94,43 @@ func (q *WildcardQuery) Searcher(ctx context.Context, i index.IndexReader, m map
 func (q *WildcardQuery) Validate() error {
 	return nil // real validation delayed until searcher constructor
 }
+
+// CheckLeadingWildcards returns an error when the query, or one of
+// the queries it is composed of, is a wildcard query starting with a
+// wildcard, a regexp query without a literal prefix, or a match phrase
+// prefix query whose final term starts with a wildcard.  Such queries
+// scan the whole term dictionary of their field, which can take very
+// long on large indexes.
+func CheckLeadingWildcards(query Query) error {
+	switch q := query.(type) {
+	case *WildcardQuery:
+		if strings.HasPrefix(q.Wildcard, "*") || strings.HasPrefix(q.Wildcard, "?") {
+			return fmt.Errorf("wildcard query '%s' starts with a wildcard", q.Wildcard)
+		}
+	case *RegexpQuery:
+		r, err := regexp.Compile(strings.TrimPrefix(q.Regexp, "^"))
+		if err != nil {
+			// left for the searcher to report
+			return nil
+		}
+		if prefix, _ := r.LiteralPrefix(); prefix == "" {
+			return fmt.Errorf("regexp query '%s' has no literal prefix", q.Regexp)
+		}
+	case *MatchPhrasePrefixQuery:
+		words := strings.Fields(q.MatchPhrasePrefix)
+		if len(words) > 0 && strings.ContainsAny(words[len(words)-1][:1], "*?") {
+			return fmt.Errorf("match phrase prefix query '%s' ends with a term "+
+				"starting with a wildcard", q.MatchPhrasePrefix)
+		}
+	case *QueryStringQuery:
+		parsed, err := q.Parse()
+		if err != nil {
+			// left for the searcher to report
+			return nil
+		}
+		return CheckLeadingWildcards(parsed)
+	}
+	return WalkChildren(query, func(_ string, child Query) (Query, error) {
+		return child, CheckLeadingWildcards(child)
+	})
+}
@@ -91,15 +91,22 @@ func (sqs *KNNQueryScorer) Score(ctx *search.SearchContext,
 		}
 	}
 	if sqs.options.Explain {
+		// explain the raw value computed by the metric, distances
+		// are inverted into the score
+		measure, combination := "similarity", "score"
+		if sqs.similarityMetric == index.EuclideanDistance {
+			measure, combination = "distance", "inverse"
+		}
 		scoreExplanation = &search.Explanation{
 			Value: score,
-			Message: fmt.Sprintf("fieldWeight(%s in doc %s), score of:",
-				sqs.queryField, knnMatch.ID),
+			Message: fmt.Sprintf("fieldWeight(%s in doc %s), %s of:",
+				sqs.queryField, knnMatch.ID, combination),
 			Children: []*search.Explanation{
 				{
-					Value: score,
-					Message: fmt.Sprintf("vector(field(%s:%s) with similarity_metric(%s)=%e",
-						sqs.queryField, knnMatch.ID, sqs.similarityMetric, score),
+					Value: knnMatch.Score,
+					Message: fmt.Sprintf("%s(field(%s:%s)) with similarity_metric(%s)=%e",
+						measure, sqs.queryField, knnMatch.ID, sqs.similarityMetric,
+						knnMatch.Score),
 				},
 			},
 		}
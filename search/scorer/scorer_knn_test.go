@@ -59,11 +59,11 @@ func TestKNNScorerExplanation(t *testing.T) {
 				Score:           0.5,
 				Expl: &search.Explanation{
 					Value:   1 / 0.5,
-					Message: "fieldWeight(desc in doc one), score of:",
+					Message: "fieldWeight(desc in doc one), inverse of:",
 					Children: []*search.Explanation{
 						{
-							Value:   1 / 0.5,
-							Message: "vector(field(desc:one) with similarity_metric(l2_norm)=2.000000e+00",
+							Value:   0.5,
+							Message: "distance(field(desc:one)) with similarity_metric(l2_norm)=5.000000e-01",
 						},
 					},
 				},
@@ -85,11 +85,11 @@ func TestKNNScorerExplanation(t *testing.T) {
 				Score:           0.0,
 				Expl: &search.Explanation{
 					Value:   maxKNNScore,
-					Message: "fieldWeight(desc in doc one), score of:",
+					Message: "fieldWeight(desc in doc one), inverse of:",
 					Children: []*search.Explanation{
 						{
-							Value:   maxKNNScore,
-							Message: "vector(field(desc:one) with similarity_metric(l2_norm)=3.402823e+38",
+							Value:   0,
+							Message: "distance(field(desc:one)) with similarity_metric(l2_norm)=0.000000e+00",
 						},
 					},
 				},
@@ -113,7 +113,31 @@ func TestKNNScorerExplanation(t *testing.T) {
 					Children: []*search.Explanation{
 						{
 							Value:   0.5,
-							Message: "vector(field(desc:one) with similarity_metric(dot_product)=5.000000e-01",
+							Message: "similarity(field(desc:one)) with similarity_metric(dot_product)=5.000000e-01",
+						},
+					},
+				},
+			},
+		},
+		{
+			vectorMatch: &index.VectorDoc{
+				ID:     index.IndexInternalID("one"),
+				Score:  0.83,
+				Vector: resVector,
+			},
+			norm: 1.0,
+			scorer: NewKNNQueryScorer(queryVector, "desc", 1.0,
+				search.SearcherOptions{Explain: true}, index.CosineSimilarity),
+			result: &search.DocumentMatch{
+				IndexInternalID: index.IndexInternalID("one"),
+				Score:           0.83,
+				Expl: &search.Explanation{
+					Value:   0.83,
+					Message: "fieldWeight(desc in doc one), score of:",
+					Children: []*search.Explanation{
+						{
+							Value:   0.83,
+							Message: "similarity(field(desc:one)) with similarity_metric(cosine)=8.300000e-01",
 						},
 					},
 				},
@@ -155,7 +179,7 @@ func TestKNNScorerExplanation(t *testing.T) {
 							Children: []*search.Explanation{
 								{
 									Value:   0.25,
-									Message: "vector(field(desc:one) with similarity_metric(dot_product)=2.500000e-01",
+									Message: "similarity(field(desc:one)) with similarity_metric(dot_product)=2.500000e-01",
 								},
 							},
 						},
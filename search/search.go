@@ -26,6 +26,7 @@ import (
 var reflectStaticSizeDocumentMatch int
 var reflectStaticSizeSearchContext int
 var reflectStaticSizeLocation int
+var reflectStaticSizeTermOffset int
 
 func init() {
 	var dm DocumentMatch
@@ -34,6 +35,8 @@ func init() {
 	reflectStaticSizeSearchContext = int(reflect.TypeOf(sc).Size())
 	var l Location
 	reflectStaticSizeLocation = int(reflect.TypeOf(l).Size())
+	var to TermOffset
+	reflectStaticSizeTermOffset = int(reflect.TypeOf(to).Size())
 }
 
 type ArrayPositions []uint64
@@ -135,6 +138,58 @@ func (t TermLocationMap) AddLocation(term string, location *Location) {
 
 type FieldTermLocationMap map[string]TermLocationMap
 
+// Fields returns the sorted names of the fields with locations
+func (f FieldTermLocationMap) Fields() []string {
+	if len(f) == 0 {
+		return nil
+	}
+	rv := make([]string, 0, len(f))
+	for field := range f {
+		rv = append(rv, field)
+	}
+	sort.Strings(rv)
+	return rv
+}
+
+// TermOffset locates a matched term in a field value by its
+// byte offsets, for highlighting on the client side.
+type TermOffset struct {
+	Term           string         `json:"term"`
+	Start          uint64         `json:"start"`
+	End            uint64         `json:"end"`
+	ArrayPositions ArrayPositions `json:"array_positions,omitempty"`
+}
+
+// Offsets returns the locations of each field as term offsets,
+// ordered by array positions and then by start offset.
+func (f FieldTermLocationMap) Offsets() map[string][]TermOffset {
+	if len(f) == 0 {
+		return nil
+	}
+	rv := make(map[string][]TermOffset, len(f))
+	for field, terms := range f {
+		var offsets []TermOffset
+		for term, locations := range terms {
+			for _, location := range locations {
+				offsets = append(offsets, TermOffset{
+					Term:           term,
+					Start:          location.Start,
+					End:            location.End,
+					ArrayPositions: location.ArrayPositions,
+				})
+			}
+		}
+		sort.Slice(offsets, func(i, j int) bool {
+			if c := offsets[i].ArrayPositions.Compare(offsets[j].ArrayPositions); c != 0 {
+				return c < 0
+			}
+			return offsets[i].Start < offsets[j].Start
+		})
+		rv[field] = offsets
+	}
+	return rv
+}
+
 type FieldTermLocation struct {
 	Field    string
 	Term     string
@@ -153,6 +208,28 @@ type DocumentMatch struct {
 	Fragments       FieldFragmentMap      `json:"fragments,omitempty"`
 	Sort            []string              `json:"sort,omitempty"`
 
+	// NormalizedScore is the score divided by the maximum score of
+	// the search, only set by SearchResult.NormalizeScores
+	NormalizedScore float64 `json:"normalized_score,omitempty"`
+
+	// Relevant reports whether the score reaches a relevance
+	// threshold, only set by SearchResult.MarkRelevant
+	Relevant *bool `json:"relevant,omitempty"`
+
+	// MatchedFields lists the fields in which the query matched,
+	// it is only populated when locations are included.
+	MatchedFields []string `json:"matched_fields,omitempty"`
+
+	// Highlights holds the byte offsets of the matched terms of
+	// each field, whatever the type of query, it is only populated
+	// when locations are included.
+	Highlights map[string][]TermOffset `json:"highlights,omitempty"`
+
+	// Checksum is a hash of the values of the returned fields, equal
+	// for hits with equal field values, only set by
+	// SearchResult.ComputeChecksums
+	Checksum string `json:"checksum,omitempty"`
+
 	// Fields contains the values for document fields listed in
 	// SearchRequest.Fields. Text fields are returned as strings, numeric
 	// fields as float64s and date fields as strings.
@@ -273,6 +350,19 @@ func (dm *DocumentMatch) Size() int {
 			size.SizeOfPtr
 	}
 
+	sizeInBytes += len(dm.Checksum)
+
+	for _, entry := range dm.MatchedFields {
+		sizeInBytes += size.SizeOfString + len(entry)
+	}
+
+	for k, v := range dm.Highlights {
+		sizeInBytes += size.SizeOfString + len(k) + size.SizeOfSlice
+		for _, offset := range v {
+			sizeInBytes += reflectStaticSizeTermOffset + len(offset.Term)
+		}
+	}
+
 	return sizeInBytes
 }
 
@@ -0,0 +1,129 @@
+//  Copyright (c) 2024 Couchbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 		http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package searcher
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/blevesearch/bleve/v2/search"
+	"github.com/blevesearch/bleve/v2/size"
+	index "github.com/blevesearch/bleve_index_api"
+)
+
+var reflectStaticSizeConstantScoreSearcher int
+
+func init() {
+	var css ConstantScoreSearcher
+	reflectStaticSizeConstantScoreSearcher = int(reflect.TypeOf(css).Size())
+}
+
+// ConstantScoreSearcher wraps any other searcher, matching the same
+// documents but replacing their scores with a constant, in the same
+// way the MatchAllSearcher scores every document it matches.
+type ConstantScoreSearcher struct {
+	child       search.Searcher
+	boost       float64
+	queryNorm   float64
+	queryWeight float64
+	options     search.SearcherOptions
+}
+
+func NewConstantScoreSearcher(ctx context.Context, s search.Searcher,
+	boost float64, options search.SearcherOptions) *ConstantScoreSearcher {
+	return &ConstantScoreSearcher{
+		child:       s,
+		boost:       boost,
+		queryNorm:   1.0,
+		queryWeight: boost,
+		options:     options,
+	}
+}
+
+func (s *ConstantScoreSearcher) Size() int {
+	return reflectStaticSizeConstantScoreSearcher + size.SizeOfPtr +
+		s.child.Size()
+}
+
+func (s *ConstantScoreSearcher) rescore(d *search.DocumentMatch) *search.DocumentMatch {
+	if d == nil {
+		return nil
+	}
+	if s.options.Score == "none" {
+		d.Score = 0
+		d.Expl = nil
+		return d
+	}
+	d.Score = s.queryWeight
+	if s.options.Explain {
+		d.Expl = &search.Explanation{
+			Value:   s.queryWeight,
+			Message: fmt.Sprintf("ConstantScore()^%f, product of:", s.boost),
+			Children: []*search.Explanation{
+				{
+					Value:   s.boost,
+					Message: "boost",
+				},
+				{
+					Value:   s.queryNorm,
+					Message: "queryNorm",
+				},
+			},
+		}
+	}
+	return d
+}
+
+func (s *ConstantScoreSearcher) Next(ctx *search.SearchContext) (*search.DocumentMatch, error) {
+	next, err := s.child.Next(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.rescore(next), nil
+}
+
+func (s *ConstantScoreSearcher) Advance(ctx *search.SearchContext, ID index.IndexInternalID) (*search.DocumentMatch, error) {
+	adv, err := s.child.Advance(ctx, ID)
+	if err != nil {
+		return nil, err
+	}
+	return s.rescore(adv), nil
+}
+
+func (s *ConstantScoreSearcher) Close() error {
+	return s.child.Close()
+}
+
+func (s *ConstantScoreSearcher) Weight() float64 {
+	return s.boost * s.boost
+}
+
+func (s *ConstantScoreSearcher) SetQueryNorm(qnorm float64) {
+	s.queryNorm = qnorm
+	s.queryWeight = s.boost * qnorm
+}
+
+func (s *ConstantScoreSearcher) Count() uint64 {
+	return s.child.Count()
+}
+
+func (s *ConstantScoreSearcher) Min() int {
+	return s.child.Min()
+}
+
+func (s *ConstantScoreSearcher) DocumentMatchPoolSize() int {
+	return s.child.DocumentMatchPoolSize()
+}
@@ -40,6 +40,25 @@ var AutoFuzzinessLowThreshold = 2
 func NewFuzzySearcher(ctx context.Context, indexReader index.IndexReader, term string,
 	prefix, fuzziness int, field string, boost float64,
 	options search.SearcherOptions) (search.Searcher, error) {
+	return newFuzzySearcher(ctx, indexReader, term, prefix, fuzziness, field,
+		boost, options, false)
+}
+
+// NewTranspositionFuzzySearcher is like NewFuzzySearcher, but
+// counts swapping two adjacent characters as a single edit, so
+// that "teh" is within a fuzziness of 1 of "the".  The candidate
+// terms are found by enumerating the field dictionary, narrowed
+// by the prefix, rather than with a levenshtein automaton.
+func NewTranspositionFuzzySearcher(ctx context.Context, indexReader index.IndexReader, term string,
+	prefix, fuzziness int, field string, boost float64,
+	options search.SearcherOptions) (search.Searcher, error) {
+	return newFuzzySearcher(ctx, indexReader, term, prefix, fuzziness, field,
+		boost, options, true)
+}
+
+func newFuzzySearcher(ctx context.Context, indexReader index.IndexReader, term string,
+	prefix, fuzziness int, field string, boost float64,
+	options search.SearcherOptions, transpositions bool) (search.Searcher, error) {
 
 	if fuzziness > MaxFuzziness {
 		return nil, fmt.Errorf("fuzziness exceeds max (%d)", MaxFuzziness)
@@ -72,7 +91,7 @@ func NewFuzzySearcher(ctx context.Context, indexReader index.IndexReader, term s
 		}
 	}
 	fuzzyCandidates, err := findFuzzyCandidateTerms(indexReader, term, fuzziness,
-		field, prefixTerm)
+		field, prefixTerm, transpositions)
 	if err != nil {
 		return nil, err
 	}
@@ -114,6 +133,11 @@ func NewAutoFuzzySearcher(ctx context.Context, indexReader index.IndexReader, te
 	return NewFuzzySearcher(ctx, indexReader, term, prefix, getAutoFuzziness(term), field, boost, options)
 }
 
+func NewAutoTranspositionFuzzySearcher(ctx context.Context, indexReader index.IndexReader, term string,
+	prefix int, field string, boost float64, options search.SearcherOptions) (search.Searcher, error) {
+	return NewTranspositionFuzzySearcher(ctx, indexReader, term, prefix, getAutoFuzziness(term), field, boost, options)
+}
+
 type fuzzyCandidates struct {
 	candidates    []string
 	editDistances []uint8
@@ -133,7 +157,7 @@ func reportIOStats(ctx context.Context, bytesRead uint64) {
 }
 
 func findFuzzyCandidateTerms(indexReader index.IndexReader, term string,
-	fuzziness int, field, prefixTerm string) (rv *fuzzyCandidates, err error) {
+	fuzziness int, field, prefixTerm string, transpositions bool) (rv *fuzzyCandidates, err error) {
 	rv = &fuzzyCandidates{
 		candidates:    make([]string, 0),
 		editDistances: make([]uint8, 0),
@@ -141,8 +165,8 @@ func findFuzzyCandidateTerms(indexReader index.IndexReader, term string,
 
 	// in case of advanced reader implementations directly call
 	// the levenshtein automaton based iterator to collect the
-	// candidate terms
-	if ir, ok := indexReader.(index.IndexReaderFuzzy); ok {
+	// candidate terms, the automaton cannot count transpositions
+	if ir, ok := indexReader.(index.IndexReaderFuzzy); ok && !transpositions {
 		fieldDict, err := ir.FieldDictFuzzy(field, term, fuzziness, prefixTerm)
 		if err != nil {
 			return nil, err
@@ -187,7 +211,11 @@ func findFuzzyCandidateTerms(indexReader index.IndexReader, term string,
 	for err == nil && tfd != nil {
 		var ld int
 		var exceeded bool
-		ld, exceeded, reuse = search.LevenshteinDistanceMaxReuseSlice(term, tfd.Term, fuzziness, reuse)
+		if transpositions {
+			ld, exceeded = search.DamerauLevenshteinDistanceMax(term, tfd.Term, fuzziness)
+		} else {
+			ld, exceeded, reuse = search.LevenshteinDistanceMaxReuseSlice(term, tfd.Term, fuzziness, reuse)
+		}
 		if !exceeded && ld <= fuzziness {
 			rv.candidates = append(rv.candidates, tfd.Term)
 			rv.editDistances = append(rv.editDistances, uint8(ld))
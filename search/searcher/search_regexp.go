@@ -33,6 +33,10 @@ type Regexp interface {
 	String() string
 }
 
+// regexpExpansionCheckInterval is the number of terms a pattern
+// is expanded to between checks of the search context
+const regexpExpansionCheckInterval = 1024
+
 // NewRegexpStringSearcher is similar to NewRegexpSearcher, but
 // additionally optimizes for index readers that handle regexp's.
 func NewRegexpStringSearcher(ctx context.Context, indexReader index.IndexReader, pattern string,
@@ -63,6 +67,17 @@ func NewRegexpStringSearcher(ctx context.Context, indexReader index.IndexReader,
 	tfd, err := fieldDict.Next()
 	for err == nil && tfd != nil {
 		candidateTerms = append(candidateTerms, tfd.Term)
+		// stop expanding the pattern as soon as it matches more terms
+		// than can be searched, or the search is canceled or reaches
+		// its deadline
+		if tooManyClauses(len(candidateTerms)) && !optionsDisjunctionOptimizable(options) {
+			return nil, tooManyClausesErr(field, len(candidateTerms))
+		}
+		if ctx != nil && len(candidateTerms)%regexpExpansionCheckInterval == 0 {
+			if err = ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
 		tfd, err = fieldDict.Next()
 	}
 	if err != nil {
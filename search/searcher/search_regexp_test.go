@@ -59,6 +59,36 @@ func TestRegexpStringSearchScorch(t *testing.T) {
 	_ = twoDocIndex.Close()
 }
 
+func TestRegexpStringSearchScorchMaxClauseCount(t *testing.T) {
+	dir, _ := os.MkdirTemp("", "scorchTwoDoc")
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+
+	twoDocIndex := initTwoDocScorch(dir)
+	defer func() {
+		_ = twoDocIndex.Close()
+	}()
+	reader, err := twoDocIndex.Reader()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = reader.Close()
+	}()
+
+	defer func(count int) {
+		DisjunctionMaxClauseCount = count
+	}(DisjunctionMaxClauseCount)
+	DisjunctionMaxClauseCount = 1
+
+	_, err = NewRegexpStringSearcher(nil, reader, ".*", "desc", 1.0,
+		search.SearcherOptions{Explain: true})
+	if err == nil {
+		t.Fatal("expected error expanding to more terms than the max clause count")
+	}
+}
+
 func internalIDMakerUpsideDown(id int) index.IndexInternalID {
 	return index.IndexInternalID(fmt.Sprintf("%d", id))
 }
@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"math"
 	"sort"
+	"strconv"
 	"strings"
 	"unicode/utf8"
 
@@ -57,9 +58,16 @@ func ParseSearchSortObj(input map[string]interface{}) (SearchSort, error) {
 			Desc: descending,
 		}, nil
 	case "score":
-		return &SortScore{
+		rv := &SortScore{
 			Desc: descending,
-		}, nil
+		}
+		if precision, ok := input["precision"].(float64); ok {
+			if precision < 0 {
+				return nil, fmt.Errorf("search sort score precision must not be negative")
+			}
+			rv.Precision = precision
+		}
+		return rv, nil
 	case "geo_distance":
 		field, ok := input["field"].(string)
 		if !ok {
@@ -118,6 +126,8 @@ func ParseSearchSortObj(input map[string]interface{}) (SearchSort, error) {
 				rv.Mode = SortFieldMin
 			case "max":
 				rv.Mode = SortFieldMax
+			case "median":
+				rv.Mode = SortFieldMedian
 			default:
 				return nil, fmt.Errorf("unknown sort field mode: %s", mode)
 			}
@@ -133,6 +143,9 @@ func ParseSearchSortObj(input map[string]interface{}) (SearchSort, error) {
 				return nil, fmt.Errorf("unknown sort field missing: %s", missing)
 			}
 		}
+		if rv.Mode == SortFieldMedian && rv.Type == SortFieldAsString {
+			return nil, fmt.Errorf("sort field mode median requires a number or date type")
+		}
 		return rv, nil
 	}
 
@@ -227,9 +240,13 @@ func (so SortOrder) Compare(cachedScoring, cachedDesc []bool, i, j *DocumentMatc
 	for x := range so {
 		c := 0
 		if cachedScoring[x] {
-			if i.Score < j.Score {
+			iScore, jScore := i.Score, j.Score
+			if ss, ok := so[x].(*SortScore); ok && ss.Precision > 0 {
+				iScore, jScore = ss.round(iScore), ss.round(jScore)
+			}
+			if iScore < jScore {
 				c = -1
-			} else if i.Score > j.Score {
+			} else if iScore > jScore {
 				c = 1
 			}
 		} else {
@@ -332,6 +349,9 @@ const (
 	SortFieldMin
 	// SortFieldMax uses the maximum value
 	SortFieldMax
+	// SortFieldMedian uses the median value, the lower of the
+	// two middle values when there is an even number of them
+	SortFieldMedian
 )
 
 // SortFieldMissing controls where documents missing a field value should be sorted
@@ -396,6 +416,9 @@ func (s *SortField) filterTermsByMode(terms [][]byte) string {
 		case SortFieldMax:
 			sort.Sort(BytesSlice(terms))
 			return string(terms[len(terms)-1])
+		case SortFieldMedian:
+			sort.Sort(BytesSlice(terms))
+			return string(terms[(len(terms)-1)/2])
 		}
 	}
 
@@ -487,6 +510,8 @@ func (s *SortField) MarshalJSON() ([]byte, error) {
 			sfm["mode"] = "min"
 		case SortFieldMax:
 			sfm["mode"] = "max"
+		case SortFieldMedian:
+			sfm["mode"] = "median"
 		}
 	}
 	if s.Type > SortFieldAuto {
@@ -563,8 +588,13 @@ func (s *SortDocID) Reverse() {
 }
 
 // SortScore will sort results by the document match score
+// When Precision is set, scores are first rounded down to a
+// multiple of it, so that documents with nearly the same score
+// compare equal and are ordered by the next sort in the order,
+// for example a date field to favor recent documents.
 type SortScore struct {
-	Desc bool
+	Desc      bool
+	Precision float64
 }
 
 // UpdateVisitor is a no-op for SortScore as it's value
@@ -572,11 +602,28 @@ type SortScore struct {
 func (s *SortScore) UpdateVisitor(field string, term []byte) {
 }
 
-// Value returns the sort value of the DocumentMatch
+// Value returns the sort value of the DocumentMatch, the score
+// rounded down to the precision when it is set
 func (s *SortScore) Value(i *DocumentMatch) string {
+	if s.Precision > 0 {
+		return strconv.FormatFloat(s.round(i.Score), 'g', -1, 64)
+	}
 	return "_score"
 }
 
+// round returns the score rounded down to a multiple of the
+// precision, the multiple being adjusted for the rounding of
+// the division so that a score at a multiple is kept as is.
+func (s *SortScore) round(score float64) float64 {
+	bucket := math.Floor(score / s.Precision)
+	if bucket*s.Precision > score {
+		bucket--
+	} else if (bucket+1)*s.Precision <= score {
+		bucket++
+	}
+	return bucket * s.Precision
+}
+
 // Descending determines the order of the sort
 func (s *SortScore) Descending() bool {
 	return s.Desc
@@ -592,6 +639,16 @@ func (s *SortScore) RequiresScoring() bool { return true }
 func (s *SortScore) RequiresFields() []string { return nil }
 
 func (s *SortScore) MarshalJSON() ([]byte, error) {
+	if s.Precision > 0 {
+		sfm := map[string]interface{}{
+			"by":        "score",
+			"precision": s.Precision,
+		}
+		if s.Desc {
+			sfm["desc"] = true
+		}
+		return json.Marshal(sfm)
+	}
 	if s.Desc {
 		return json.Marshal("-_score")
 	}
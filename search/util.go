@@ -135,6 +135,22 @@ const MinGeoBufPoolSize = 24
 
 type GeoBufferPoolCallbackFunc func() *s2.GeoBufferPool
 
+const SearchWarningCallbackKey = "_search_warning_callback_key"
+
+// SearchWarningCallbackFunc receives the problems found with a query
+// while building its searchers which do not prevent the search.
+type SearchWarningCallbackFunc func(warning string)
+
+// RecordSearchWarning passes the warning to the callback of the
+// context, if any.
+func RecordSearchWarning(ctx context.Context, warning string) {
+	if ctx != nil {
+		if callbackFn, ok := ctx.Value(SearchWarningCallbackKey).(SearchWarningCallbackFunc); ok {
+			callbackFn(warning)
+		}
+	}
+}
+
 const KnnPreSearchDataKey = "_knn_pre_search_data_key"
 
 const PreSearchKey = "_presearch_key"
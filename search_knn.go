@@ -40,10 +40,12 @@ var BleveMaxK = int64(10000)
 type SearchRequest struct {
 	ClientContextID  string            `json:"client_context_id,omitempty"`
 	Query            query.Query       `json:"query"`
+	Filter           query.Query       `json:"filter,omitempty"`
 	Size             int               `json:"size"`
 	From             int               `json:"from"`
 	Highlight        *HighlightRequest `json:"highlight"`
 	Fields           []string          `json:"fields"`
+	ExcludeFields    []string          `json:"exclude_fields,omitempty"`
 	Facets           FacetsRequest     `json:"facets"`
 	Explain          bool              `json:"explain"`
 	Sort             search.SortOrder  `json:"sort"`
@@ -51,6 +53,9 @@ type SearchRequest struct {
 	Score            string            `json:"score,omitempty"`
 	SearchAfter      []string          `json:"search_after"`
 	SearchBefore     []string          `json:"search_before"`
+	TieBreak         bool              `json:"tie_break,omitempty"`
+	FacetMode        string            `json:"facet_mode,omitempty"`
+	IncludeWarnings  bool              `json:"include_warnings,omitempty"`
 
 	KNN         []*KNNRequest `json:"knn"`
 	KNNOperator knnOperator   `json:"knn_operator"`
@@ -134,10 +139,12 @@ func (r *SearchRequest) UnmarshalJSON(input []byte) error {
 
 	var temp struct {
 		Q                json.RawMessage   `json:"query"`
+		Filter           json.RawMessage   `json:"filter"`
 		Size             *int              `json:"size"`
 		From             int               `json:"from"`
 		Highlight        *HighlightRequest `json:"highlight"`
 		Fields           []string          `json:"fields"`
+		ExcludeFields    []string          `json:"exclude_fields"`
 		Facets           FacetsRequest     `json:"facets"`
 		Explain          bool              `json:"explain"`
 		Sort             []json.RawMessage `json:"sort"`
@@ -145,6 +152,9 @@ func (r *SearchRequest) UnmarshalJSON(input []byte) error {
 		Score            string            `json:"score"`
 		SearchAfter      []string          `json:"search_after"`
 		SearchBefore     []string          `json:"search_before"`
+		TieBreak         bool              `json:"tie_break"`
+		FacetMode        string            `json:"facet_mode"`
+		IncludeWarnings  bool              `json:"include_warnings"`
 		KNN              []*tempKNNReq     `json:"knn"`
 		KNNOperator      knnOperator       `json:"knn_operator"`
 		PreSearchData    json.RawMessage   `json:"pre_search_data"`
@@ -172,14 +182,26 @@ func (r *SearchRequest) UnmarshalJSON(input []byte) error {
 	r.Explain = temp.Explain
 	r.Highlight = temp.Highlight
 	r.Fields = temp.Fields
+	r.ExcludeFields = temp.ExcludeFields
 	r.Facets = temp.Facets
 	r.IncludeLocations = temp.IncludeLocations
 	r.Score = temp.Score
 	r.SearchAfter = temp.SearchAfter
 	r.SearchBefore = temp.SearchBefore
-	r.Query, err = query.ParseQuery(temp.Q)
-	if err != nil {
-		return err
+	r.TieBreak = temp.TieBreak
+	r.FacetMode = temp.FacetMode
+	r.IncludeWarnings = temp.IncludeWarnings
+	if temp.Q != nil || temp.Filter == nil {
+		r.Query, err = query.ParseQuery(temp.Q)
+		if err != nil {
+			return err
+		}
+	}
+	if temp.Filter != nil {
+		r.Filter, err = query.ParseQuery(temp.Filter)
+		if err != nil {
+			return err
+		}
 	}
 
 	if r.Size < 0 {
@@ -226,10 +248,12 @@ func (r *SearchRequest) UnmarshalJSON(input []byte) error {
 func copySearchRequest(req *SearchRequest, preSearchData map[string]interface{}) *SearchRequest {
 	rv := SearchRequest{
 		Query:            req.Query,
+		Filter:           req.Filter,
 		Size:             req.Size + req.From,
 		From:             0,
 		Highlight:        req.Highlight,
 		Fields:           req.Fields,
+		ExcludeFields:    req.ExcludeFields,
 		Facets:           req.Facets,
 		Explain:          req.Explain,
 		Sort:             req.Sort.Copy(),
@@ -237,6 +261,9 @@ func copySearchRequest(req *SearchRequest, preSearchData map[string]interface{})
 		Score:            req.Score,
 		SearchAfter:      req.SearchAfter,
 		SearchBefore:     req.SearchBefore,
+		TieBreak:         req.TieBreak,
+		FacetMode:        req.FacetMode,
+		IncludeWarnings:  req.IncludeWarnings,
 		KNN:              req.KNN,
 		KNNOperator:      req.KNNOperator,
 		PreSearchData:    preSearchData,
@@ -275,7 +302,7 @@ func createKNNQuery(req *SearchRequest, eligibleDocsMap map[int][]index.IndexInt
 			knnQuery.SetBoost(knn.Boost.Value())
 			knnQuery.SetParams(knn.Params)
 			if len(eligibleDocsMap[i]) > 0 {
-				knnQuery.SetFilterQuery(knn.FilterQuery)
+				knnQuery.SetFilterQuery(knnFilterQuery(req, knn))
 				filterResults, exists := eligibleDocsMap[i]
 				if exists {
 					knnQuery.SetFilterResults(filterResults)
@@ -292,6 +319,29 @@ func createKNNQuery(req *SearchRequest, eligibleDocsMap map[int][]index.IndexInt
 	return nil, nil, 0, nil
 }
 
+// knnFilterQueries returns the filters of the kNN requests of the request.
+func knnFilterQueries(req *SearchRequest) []query.Query {
+	rv := make([]query.Query, 0, len(req.KNN))
+	for _, knn := range req.KNN {
+		rv = append(rv, knn.FilterQuery)
+	}
+	return rv
+}
+
+// knnFilterQuery returns the filter of the kNN request combined with
+// the Filter of the search request, so that the kNN hits are limited
+// to the documents the search request is filtered to, as the hits of
+// its query are.
+func knnFilterQuery(req *SearchRequest, knn *KNNRequest) query.Query {
+	switch {
+	case req.Filter == nil:
+		return knn.FilterQuery
+	case knn.FilterQuery == nil:
+		return req.Filter
+	}
+	return query.NewConjunctionQuery([]query.Query{knn.FilterQuery, req.Filter})
+}
+
 func validateKNN(req *SearchRequest) error {
 	if req.KNN != nil &&
 		req.KNNOperator != "" &&
@@ -369,7 +419,7 @@ func (i *indexImpl) runKnnCollector(ctx context.Context, req *SearchRequest, rea
 	for idx, knnReq := range req.KNN {
 		// TODO Can use goroutines for this filter query stuff - do it if perf results
 		// show this to be significantly slow otherwise.
-		filterQ := knnReq.FilterQuery
+		filterQ := knnFilterQuery(req, knnReq)
 		if filterQ == nil {
 			requiresFiltering[idx] = false
 			continue
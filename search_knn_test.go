@@ -23,11 +23,14 @@ import (
 	"encoding/base64"
 	"encoding/binary"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math"
 	"math/rand"
+	"reflect"
 	"sort"
 	"strconv"
+	"strings"
 	"sync"
 	"testing"
 
@@ -1451,6 +1454,79 @@ func TestKNNFiltering(t *testing.T) {
 	}
 }
 
+func TestKNNWithRequestFilter(t *testing.T) {
+	tmpIndexPath := createTmpIndexPath(t)
+	defer cleanupTmpIndexPath(t, tmpIndexPath)
+
+	const dims = 5
+	getRandomVector := func() []float32 {
+		vec := make([]float32, dims)
+		for i := 0; i < dims; i++ {
+			vec[i] = rand.Float32()
+		}
+		return vec
+	}
+
+	indexMapping := NewIndexMapping()
+	indexMapping.DefaultAnalyzer = "en"
+	vecFieldMapping := mapping.NewVectorFieldMapping()
+	vecFieldMapping.Index = true
+	vecFieldMapping.Dims = dims
+	vecFieldMapping.Similarity = "dot_product"
+	indexMapping.DefaultMapping.AddFieldMappingsAt("vector", vecFieldMapping)
+
+	index, err := New(tmpIndexPath, indexMapping)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err := index.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	batch := index.NewBatch()
+	for i := 0; i < 10; i++ {
+		err = batch.Index(strconv.Itoa(i), map[string]interface{}{
+			"content": strconv.Itoa(i + 1000),
+			"vector":  getRandomVector(),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = index.Batch(batch)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the kNN hits are restricted to the documents matching the filter
+	searchRequest := NewSearchRequest(NewMatchNoneQuery())
+	searchRequest.Filter = query.NewTermQuery("1004")
+	searchRequest.AddKNN("vector", getRandomVector(), 10, 1.0)
+	res, err := index.Search(searchRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Hits) != 1 || res.Hits[0].ID != "4" {
+		t.Errorf("expected only the filtered document, got %v", res.Hits)
+	}
+
+	// and to those matching the kNN filter as well
+	searchRequest = NewSearchRequest(NewMatchNoneQuery())
+	searchRequest.Filter = query.NewTermQuery("1004")
+	searchRequest.AddKNNWithFilter("vector", getRandomVector(), 10, 1.0,
+		query.NewTermQuery("1005"))
+	res, err = index.Search(searchRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Hits) != 0 {
+		t.Errorf("expected no document matching both filters, got %v", res.Hits)
+	}
+}
+
 // -----------------------------------------------------------------------------
 // Test nested vectors
 
@@ -1599,3 +1675,110 @@ func TestNumVecsStat(t *testing.T) {
 		}
 	}
 }
+
+func TestOpenOrCreateVectorDimsMismatch(t *testing.T) {
+	tmpIndexPath := createTmpIndexPath(t)
+	defer cleanupTmpIndexPath(t, tmpIndexPath)
+
+	buildMapping := func(dims int) mapping.IndexMapping {
+		vecFieldMapping := mapping.NewVectorFieldMapping()
+		vecFieldMapping.Dims = dims
+		vecFieldMapping.Similarity = index.CosineSimilarity
+		rv := NewIndexMapping()
+		rv.DefaultMapping.AddFieldMappingsAt("vector", vecFieldMapping)
+		return rv
+	}
+
+	idx, err := OpenOrCreate(tmpIndexPath, buildMapping(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = idx.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	idx, err = OpenOrCreate(tmpIndexPath, buildMapping(4))
+	if !errors.Is(err, ErrorIndexMappingMismatch) {
+		t.Fatalf("expected mapping mismatch error, got %v", err)
+	}
+	if idx != nil {
+		t.Errorf("expected no index returned on mismatch")
+	}
+	if !strings.Contains(err.Error(), "vector.fields.0.dims") ||
+		!strings.Contains(err.Error(), "has 3, requested 4") {
+		t.Errorf("expected error to describe the dims change, got: %v", err)
+	}
+}
+
+func TestKNNFacets(t *testing.T) {
+	vecFieldMapping := mapping.NewVectorFieldMapping()
+	vecFieldMapping.Dims = 3
+	vecFieldMapping.Similarity = index.InnerProduct
+	indexMapping := NewIndexMapping()
+	indexMapping.DefaultMapping.AddFieldMappingsAt("vector", vecFieldMapping)
+
+	dataset := map[string]map[string]interface{}{
+		"0": {"color": "red", "vector": []float32{1, 0, 0}},
+		"1": {"color": "red", "vector": []float32{0.9, 0.1, 0}},
+		"2": {"color": "green", "vector": []float32{0.8, 0.2, 0}},
+		"3": {"color": "green", "vector": []float32{0, 1, 0}},
+		"4": {"color": "blue", "vector": []float32{0, 0, 1}},
+		"5": {"color": "blue", "vector": []float32{0.1, 0.9, 0}},
+	}
+
+	// the first index holds all documents, the other two
+	// split them between themselves to be searched by an alias
+	indexes := make([]Index, 0, 3)
+	for i := 0; i < 3; i++ {
+		tmpIndexPath := createTmpIndexPath(t)
+		defer cleanupTmpIndexPath(t, tmpIndexPath)
+		idx, err := New(tmpIndexPath, indexMapping)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() {
+			err := idx.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+		}()
+		indexes = append(indexes, idx)
+	}
+	for id, doc := range dataset {
+		err := indexes[0].Index(id, doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		idNum, _ := strconv.Atoi(id)
+		err = indexes[1+idNum%2].Index(id, doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expected := map[string]int{"red": 2, "green": 1}
+	for _, idx := range []Index{indexes[0], NewIndexAlias(indexes[1:]...)} {
+		sr := NewSearchRequest(NewMatchNoneQuery())
+		sr.AddKNN("vector", []float32{1, 0, 0}, 3, 1.0)
+		sr.AddFacet("colors", NewFacetRequest("color", 10))
+		res, err := idx.Search(sr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(res.Hits) != 3 {
+			t.Fatalf("expected 3 hits, got %d", len(res.Hits))
+		}
+		colors := res.Facets["colors"]
+		if colors == nil || colors.Total != 3 {
+			t.Fatalf("expected facet over the 3 neighbors, got %+v", colors)
+		}
+		got := make(map[string]int)
+		for _, term := range colors.Terms.Terms() {
+			got[term.Term] = term.Count
+		}
+		if !reflect.DeepEqual(got, expected) {
+			t.Errorf("expected facet buckets %v, got %v", expected, got)
+		}
+	}
+}
@@ -32,7 +32,9 @@ const supportForVectorSearch = false
 
 // A SearchRequest describes all the parameters
 // needed to search the index.
-// Query is required.
+// Query is required, unless a Filter is provided.
+// Filter restricts the results to documents also
+// matching it, without affecting their score.
 // Size/From describe how much and which part of the
 // result set to return.
 // Highlight describes optional search result
@@ -47,16 +49,25 @@ const supportForVectorSearch = false
 // Score controls the kind of scoring performed
 // SearchAfter supports deep paging by providing a minimum sort key
 // SearchBefore supports deep paging by providing a maximum sort key
+// TieBreak appends a sort on the document ID, unless the sort already
+// includes one, so that hits sorting equally keep a stable order.
+// FacetMode decides whether facets are computed over the results
+// narrowed by the selected facet buckets, or over the results
+// before the selection.
+// IncludeWarnings reports the problems found with the request which
+// do not fail the search in the Warnings of the result.
 // sortFunc specifies the sort implementation to use for sorting results.
 //
 // A special field named "*" can be used to return all fields.
 type SearchRequest struct {
 	ClientContextID  string            `json:"client_context_id,omitempty"`
 	Query            query.Query       `json:"query"`
+	Filter           query.Query       `json:"filter,omitempty"`
 	Size             int               `json:"size"`
 	From             int               `json:"from"`
 	Highlight        *HighlightRequest `json:"highlight"`
 	Fields           []string          `json:"fields"`
+	ExcludeFields    []string          `json:"exclude_fields,omitempty"`
 	Facets           FacetsRequest     `json:"facets"`
 	Explain          bool              `json:"explain"`
 	Sort             search.SortOrder  `json:"sort"`
@@ -64,6 +75,9 @@ type SearchRequest struct {
 	Score            string            `json:"score,omitempty"`
 	SearchAfter      []string          `json:"search_after"`
 	SearchBefore     []string          `json:"search_before"`
+	TieBreak         bool              `json:"tie_break,omitempty"`
+	FacetMode        string            `json:"facet_mode,omitempty"`
+	IncludeWarnings  bool              `json:"include_warnings,omitempty"`
 
 	// PreSearchData will be a  map that will be used
 	// in the second phase of any 2-phase search, to provide additional
@@ -85,10 +99,12 @@ type SearchRequest struct {
 func (r *SearchRequest) UnmarshalJSON(input []byte) error {
 	var temp struct {
 		Q                json.RawMessage   `json:"query"`
+		Filter           json.RawMessage   `json:"filter"`
 		Size             *int              `json:"size"`
 		From             int               `json:"from"`
 		Highlight        *HighlightRequest `json:"highlight"`
 		Fields           []string          `json:"fields"`
+		ExcludeFields    []string          `json:"exclude_fields"`
 		Facets           FacetsRequest     `json:"facets"`
 		Explain          bool              `json:"explain"`
 		Sort             []json.RawMessage `json:"sort"`
@@ -96,6 +112,9 @@ func (r *SearchRequest) UnmarshalJSON(input []byte) error {
 		Score            string            `json:"score"`
 		SearchAfter      []string          `json:"search_after"`
 		SearchBefore     []string          `json:"search_before"`
+		TieBreak         bool              `json:"tie_break"`
+		FacetMode        string            `json:"facet_mode"`
+		IncludeWarnings  bool              `json:"include_warnings"`
 		PreSearchData    json.RawMessage   `json:"pre_search_data"`
 	}
 
@@ -121,14 +140,26 @@ func (r *SearchRequest) UnmarshalJSON(input []byte) error {
 	r.Explain = temp.Explain
 	r.Highlight = temp.Highlight
 	r.Fields = temp.Fields
+	r.ExcludeFields = temp.ExcludeFields
 	r.Facets = temp.Facets
 	r.IncludeLocations = temp.IncludeLocations
 	r.Score = temp.Score
 	r.SearchAfter = temp.SearchAfter
 	r.SearchBefore = temp.SearchBefore
-	r.Query, err = query.ParseQuery(temp.Q)
-	if err != nil {
-		return err
+	r.TieBreak = temp.TieBreak
+	r.FacetMode = temp.FacetMode
+	r.IncludeWarnings = temp.IncludeWarnings
+	if temp.Q != nil || temp.Filter == nil {
+		r.Query, err = query.ParseQuery(temp.Q)
+		if err != nil {
+			return err
+		}
+	}
+	if temp.Filter != nil {
+		r.Filter, err = query.ParseQuery(temp.Filter)
+		if err != nil {
+			return err
+		}
 	}
 
 	if r.Size < 0 {
@@ -153,10 +184,12 @@ func (r *SearchRequest) UnmarshalJSON(input []byte) error {
 func copySearchRequest(req *SearchRequest, preSearchData map[string]interface{}) *SearchRequest {
 	rv := SearchRequest{
 		Query:            req.Query,
+		Filter:           req.Filter,
 		Size:             req.Size + req.From,
 		From:             0,
 		Highlight:        req.Highlight,
 		Fields:           req.Fields,
+		ExcludeFields:    req.ExcludeFields,
 		Facets:           req.Facets,
 		Explain:          req.Explain,
 		Sort:             req.Sort.Copy(),
@@ -164,6 +197,9 @@ func copySearchRequest(req *SearchRequest, preSearchData map[string]interface{})
 		Score:            req.Score,
 		SearchAfter:      req.SearchAfter,
 		SearchBefore:     req.SearchBefore,
+		TieBreak:         req.TieBreak,
+		FacetMode:        req.FacetMode,
+		IncludeWarnings:  req.IncludeWarnings,
 		PreSearchData:    preSearchData,
 	}
 	return &rv
@@ -184,6 +220,10 @@ func requestHasKNN(req *SearchRequest) bool {
 	return false
 }
 
+func knnFilterQueries(req *SearchRequest) []query.Query {
+	return nil
+}
+
 func addKnnToDummyRequest(dummyReq *SearchRequest, realReq *SearchRequest) {
 }
 
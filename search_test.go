@@ -15,10 +15,12 @@
 package bleve
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"math"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"testing"
@@ -39,6 +41,7 @@ import (
 	"github.com/blevesearch/bleve/v2/analysis/datetime/timestamp/milliseconds"
 	"github.com/blevesearch/bleve/v2/analysis/datetime/timestamp/nanoseconds"
 	"github.com/blevesearch/bleve/v2/analysis/datetime/timestamp/seconds"
+	"github.com/blevesearch/bleve/v2/analysis/lang/en"
 	"github.com/blevesearch/bleve/v2/analysis/token/length"
 	"github.com/blevesearch/bleve/v2/analysis/token/lowercase"
 	"github.com/blevesearch/bleve/v2/analysis/token/shingle"
@@ -3746,3 +3749,2778 @@ func TestAutoFuzzy(t *testing.T) {
 		}
 	}
 }
+
+func TestSearchExcludeFields(t *testing.T) {
+	tmpIndexPath := createTmpIndexPath(t)
+	defer cleanupTmpIndexPath(t, tmpIndexPath)
+
+	idx, err := New(tmpIndexPath, NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err := idx.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	doc := map[string]interface{}{
+		"title":   "bleve",
+		"content": "search library",
+		"vector":  []interface{}{1.0, 2.0, 3.0},
+		"meta": map[string]interface{}{
+			"author": "marty",
+			"year":   2014,
+		},
+	}
+	if err = idx.Index("doc", doc); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		fields        []string
+		excludeFields []string
+		expect        []string
+	}{
+		{
+			fields:        []string{"*"},
+			excludeFields: []string{"vector"},
+			expect:        []string{"content", "meta.author", "meta.year", "title"},
+		},
+		{
+			fields:        []string{"*"},
+			excludeFields: []string{"vector", "meta.*"},
+			expect:        []string{"content", "title"},
+		},
+		{
+			fields: []string{"meta.*", "title"},
+			expect: []string{"meta.author", "meta.year", "title"},
+		},
+	}
+
+	for _, test := range tests {
+		sr := NewSearchRequest(NewMatchQuery("bleve"))
+		sr.Fields = test.fields
+		sr.ExcludeFields = test.excludeFields
+		res, err := idx.Search(sr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(res.Hits) != 1 {
+			t.Fatalf("expected 1 hit, got %d", len(res.Hits))
+		}
+		got := make([]string, 0, len(res.Hits[0].Fields))
+		for k := range res.Hits[0].Fields {
+			got = append(got, k)
+		}
+		sort.Strings(got)
+		if !reflect.DeepEqual(got, test.expect) {
+			t.Errorf("fields %v excluding %v: expected %v, got %v",
+				test.fields, test.excludeFields, test.expect, got)
+		}
+	}
+}
+
+func TestMatchPhrasePrefixQuery(t *testing.T) {
+	tmpIndexPath := createTmpIndexPath(t)
+	defer cleanupTmpIndexPath(t, tmpIndexPath)
+
+	idx, err := New(tmpIndexPath, NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err := idx.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	docs := map[string]string{
+		"fox":   "the quick brown fox jumps",
+		"fog":   "a quick brown fog rolls in",
+		"order": "the brown quick fox",
+		"other": "quick brown bears",
+	}
+	for id, body := range docs {
+		if err = idx.Index(id, map[string]interface{}{"body": body}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		phrase string
+		expect []string
+	}{
+		{
+			phrase: "quick brown fo",
+			expect: []string{"fog", "fox"},
+		},
+		{
+			phrase: "quick brown fox",
+			expect: []string{"fox"},
+		},
+		{
+			phrase: "brown qu",
+			expect: []string{"order"},
+		},
+		{
+			phrase: "quick brown z",
+			expect: []string{},
+		},
+	}
+
+	for _, test := range tests {
+		q := NewMatchPhrasePrefixQuery(test.phrase)
+		q.SetField("body")
+		sr := NewSearchRequest(q)
+		sr.SortBy([]string{"_id"})
+		res, err := idx.Search(sr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := make([]string, 0, len(res.Hits))
+		for _, hit := range res.Hits {
+			got = append(got, hit.ID)
+		}
+		if !reflect.DeepEqual(got, test.expect) {
+			t.Errorf("phrase prefix %q: expected %v, got %v", test.phrase, test.expect, got)
+		}
+	}
+}
+
+func TestMatchQueryCutoffFrequency(t *testing.T) {
+	tmpIndexPath := createTmpIndexPath(t)
+	defer cleanupTmpIndexPath(t, tmpIndexPath)
+
+	idx, err := New(tmpIndexPath, NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err := idx.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	docs := map[string]string{
+		"1": "data fox",
+		"2": "data dog",
+		"3": "data cat",
+		"4": "data bird",
+		"5": "fox runs",
+	}
+	for id, body := range docs {
+		if err = idx.Index(id, map[string]interface{}{"body": body}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		cutoff float64
+		expect []string
+	}{
+		{
+			// without a cutoff the common term is required
+			cutoff: 0,
+			expect: []string{"1"},
+		},
+		{
+			// "data" appears in more than half the documents
+			cutoff: 0.5,
+			expect: []string{"1", "5"},
+		},
+		{
+			// absolute document count
+			cutoff: 3,
+			expect: []string{"1", "5"},
+		},
+		{
+			// neither term exceeds the cutoff
+			cutoff: 10,
+			expect: []string{"1"},
+		},
+	}
+
+	for _, test := range tests {
+		q := NewMatchQuery("data fox")
+		q.SetField("body")
+		q.SetOperator(query.MatchQueryOperatorAnd)
+		q.SetCutoffFrequency(test.cutoff)
+		res, err := idx.Search(NewSearchRequest(q))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := make([]string, 0, len(res.Hits))
+		for _, hit := range res.Hits {
+			got = append(got, hit.ID)
+		}
+		if !reflect.DeepEqual(got, test.expect) {
+			t.Errorf("cutoff %v: expected %v, got %v", test.cutoff, test.expect, got)
+		}
+	}
+}
+
+func TestConstantScoreQuery(t *testing.T) {
+	tmpIndexPath := createTmpIndexPath(t)
+	defer cleanupTmpIndexPath(t, tmpIndexPath)
+
+	idx, err := New(tmpIndexPath, NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err := idx.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	docs := map[string]string{
+		"once":  "fox and dog",
+		"often": "fox fox fox fox",
+		"never": "cat and dog",
+	}
+	for id, body := range docs {
+		if err = idx.Index(id, map[string]interface{}{"body": body}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tq := NewTermQuery("fox")
+	tq.SetField("body")
+	res, err := idx.Search(NewSearchRequest(tq))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Hits) != 2 {
+		t.Fatalf("expected 2 hits, got %d", len(res.Hits))
+	}
+	if res.Hits[0].Score == res.Hits[1].Score {
+		t.Fatalf("expected term frequency to influence scores, got %f for both",
+			res.Hits[0].Score)
+	}
+
+	csq := NewConstantScoreQuery(tq)
+	csq.SetBoost(3)
+	sr := NewSearchRequest(csq)
+	sr.Explain = true
+	res, err = idx.Search(sr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Hits) != 2 {
+		t.Fatalf("expected 2 hits, got %d", len(res.Hits))
+	}
+	for _, hit := range res.Hits {
+		if hit.Score != 3 {
+			t.Errorf("expected constant score 3 for %s, got %f", hit.ID, hit.Score)
+		}
+		if hit.Expl == nil || hit.Expl.Value != hit.Score {
+			t.Errorf("expected explanation matching score for %s, got %v", hit.ID, hit.Expl)
+		}
+	}
+
+	// combined with a scoring clause, only that clause separates the hits
+	mq := NewMatchQuery("dog")
+	mq.SetField("body")
+	res, err = idx.Search(NewSearchRequest(NewDisjunctionQuery(csq, mq)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Hits) != 3 {
+		t.Fatalf("expected 3 hits, got %d", len(res.Hits))
+	}
+	if res.Hits[0].ID != "once" {
+		t.Errorf("expected document matching both clauses first, got %s", res.Hits[0].ID)
+	}
+}
+
+func TestSearchNestedFieldPaths(t *testing.T) {
+	tmpIndexPath := createTmpIndexPath(t)
+	defer cleanupTmpIndexPath(t, tmpIndexPath)
+
+	authorMapping := NewDocumentMapping()
+	authorMapping.AddFieldMappingsAt("name", NewTextFieldMapping())
+	docMapping := NewDocumentMapping()
+	docMapping.AddSubDocumentMapping("author", authorMapping)
+	indexMapping := NewIndexMapping()
+	indexMapping.DefaultMapping = docMapping
+
+	idx, err := New(tmpIndexPath, indexMapping)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err := idx.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	err = idx.Index("doc", map[string]interface{}{
+		"title": "bleve",
+		"author": map[string]interface{}{
+			"name": "marty",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		field string
+		hits  int
+	}{
+		{
+			field: "author.name",
+			hits:  1,
+		},
+		{
+			field: "author",
+			hits:  0,
+		},
+	}
+	for _, test := range tests {
+		q := NewMatchQuery("marty")
+		q.SetField(test.field)
+		res, err := idx.Search(NewSearchRequest(q))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(res.Hits) != test.hits {
+			t.Errorf("field %s: expected %d hits, got %d", test.field, test.hits, len(res.Hits))
+		}
+	}
+}
+
+func TestQueryStringFieldBoosts(t *testing.T) {
+	tmpIndexPath := createTmpIndexPath(t)
+	defer cleanupTmpIndexPath(t, tmpIndexPath)
+
+	idx, err := New(tmpIndexPath, NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err := idx.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	docs := map[string]map[string]interface{}{
+		"inbody": {
+			"title": "a short note",
+			"body":  "bleve",
+		},
+		"intitle": {
+			"title": "bleve",
+			"body":  "a short note",
+		},
+		"neither": {
+			"title": "a short note",
+			"body":  "nothing",
+		},
+	}
+	for id, doc := range docs {
+		if err = idx.Index(id, doc); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	search := func(q *query.QueryStringQuery) []string {
+		if err := q.Validate(); err != nil {
+			t.Fatal(err)
+		}
+		sr := NewSearchRequest(q)
+		sr.SortBy([]string{"-_score", "_id"})
+		res, err := idx.Search(sr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rv := make([]string, 0, len(res.Hits))
+		for _, hit := range res.Hits {
+			rv = append(rv, hit.ID)
+		}
+		return rv
+	}
+
+	// unqualified terms are searched across the boosted fields
+	q := NewQueryStringQuery("bleve")
+	q.SetFieldBoost("title", 3)
+	q.SetFieldBoost("body", 1)
+	if got, want := search(q), []string{"intitle", "inbody"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	q = NewQueryStringQuery("bleve")
+	q.SetFieldBoost("title", 1)
+	q.SetFieldBoost("body", 3)
+	if got, want := search(q), []string{"inbody", "intitle"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	// field qualified terms have their boost multiplied
+	q = NewQueryStringQuery("title:bleve body:bleve")
+	q.SetFieldBoost("body", 5)
+	if got, want := search(q), []string{"inbody", "intitle"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	q = NewQueryStringQuery("bleve")
+	q.SetFieldBoost("", 2)
+	if err := q.Validate(); err == nil {
+		t.Errorf("expected error validating empty field name")
+	}
+	q = NewQueryStringQuery("bleve")
+	q.SetFieldBoost("title", -1)
+	if err := q.Validate(); err == nil {
+		t.Errorf("expected error validating negative boost")
+	}
+}
+
+func TestSearchRequestFilter(t *testing.T) {
+	tmpIndexPath := createTmpIndexPath(t)
+	defer cleanupTmpIndexPath(t, tmpIndexPath)
+
+	idx, err := New(tmpIndexPath, NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err := idx.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	docs := map[string]map[string]interface{}{
+		"a": {"category": "books", "price": 10, "title": "go programming"},
+		"b": {"category": "books", "price": 15, "title": "go go go programming"},
+		"c": {"category": "books", "price": 30, "title": "go programming"},
+		"d": {"category": "music", "price": 5, "title": "go programming"},
+	}
+	batch := idx.NewBatch()
+	for id, doc := range docs {
+		if err = batch.Index(id, doc); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = idx.Batch(batch); err != nil {
+		t.Fatal(err)
+	}
+
+	buildFilter := func() query.Query {
+		category := NewTermQuery("books")
+		category.SetField("category")
+		maxPrice := 20.0
+		price := NewNumericRangeQuery(nil, &maxPrice)
+		price.SetField("price")
+		return NewConjunctionQuery(category, price)
+	}
+
+	// filter only, every matching document scores the same
+	sr := NewSearchRequest(nil)
+	sr.SetFilter(buildFilter())
+	res, err := idx.Search(sr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Total != 2 {
+		t.Fatalf("expected 2 hits, got %d", res.Total)
+	}
+	if res.Hits[0].Score != res.Hits[1].Score {
+		t.Errorf("expected uniform scores, got %f and %f", res.Hits[0].Score, res.Hits[1].Score)
+	}
+
+	// query and filter, the filter does not change the relevance
+	match := NewMatchQuery("go")
+	match.SetField("title")
+	unfiltered, err := idx.Search(NewSearchRequest(match))
+	if err != nil {
+		t.Fatal(err)
+	}
+	unfilteredScores := make(map[string]float64)
+	for _, hit := range unfiltered.Hits {
+		unfilteredScores[hit.ID] = hit.Score
+	}
+
+	sr = NewSearchRequest(match)
+	sr.SetFilter(buildFilter())
+	res, err = idx.Search(sr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Total != 2 {
+		t.Fatalf("expected 2 hits, got %d", res.Total)
+	}
+	if res.Hits[0].ID != "b" || res.Hits[1].ID != "a" {
+		t.Errorf("expected hits b, a got %s, %s", res.Hits[0].ID, res.Hits[1].ID)
+	}
+	for _, hit := range res.Hits {
+		if math.Abs(hit.Score-unfilteredScores[hit.ID]) > 1e-9 {
+			t.Errorf("expected filter not to affect score of %s, got %f, want %f",
+				hit.ID, hit.Score, unfilteredScores[hit.ID])
+		}
+	}
+
+	// filter only request parsed from JSON
+	var jsonReq SearchRequest
+	err = json.Unmarshal([]byte(`{"filter":{"term":"music","field":"category"}}`), &jsonReq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if jsonReq.Query != nil {
+		t.Errorf("expected no query, got %v", jsonReq.Query)
+	}
+	res, err = idx.Search(&jsonReq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Total != 1 || res.Hits[0].ID != "d" {
+		t.Errorf("expected only hit d, got %v", res.Hits)
+	}
+}
+
+func TestSortScorePrecision(t *testing.T) {
+	tmpIndexPath := createTmpIndexPath(t)
+	defer cleanupTmpIndexPath(t, tmpIndexPath)
+
+	idx, err := New(tmpIndexPath, NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err := idx.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	docs := map[string]map[string]interface{}{
+		"best": {"title": "bleve bleve bleve", "published": "2010-01-01T00:00:00Z"},
+		"old":  {"title": "bleve search", "published": "2020-01-01T00:00:00Z"},
+		"new":  {"title": "bleve search engine", "published": "2024-01-01T00:00:00Z"},
+	}
+	batch := idx.NewBatch()
+	for id, doc := range docs {
+		if err = batch.Index(id, doc); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = idx.Batch(batch); err != nil {
+		t.Fatal(err)
+	}
+
+	q := NewMatchQuery("bleve")
+	q.SetField("title")
+
+	checkOrder := func(sort search.SortOrder, expected []string) *SearchResult {
+		sr := NewSearchRequest(q)
+		sr.SortByCustom(sort)
+		res, err := idx.Search(sr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := make([]string, 0, len(res.Hits))
+		for _, hit := range res.Hits {
+			got = append(got, hit.ID)
+		}
+		if !reflect.DeepEqual(got, expected) {
+			t.Errorf("expected order %v, got %v", expected, got)
+		}
+		return res
+	}
+
+	// plain relevance order
+	checkOrder(search.SortOrder{
+		&search.SortScore{Desc: true},
+		&search.SortField{Field: "published", Desc: true},
+	}, []string{"best", "old", "new"})
+
+	// scores of old and new fall in the same bucket,
+	// so the more recent document comes first
+	res := checkOrder(search.SortOrder{
+		&search.SortScore{Desc: true, Precision: 0.2},
+		&search.SortField{Field: "published", Desc: true},
+	}, []string{"best", "new", "old"})
+	// the hits report the rounded score as their sort value
+	for _, hit := range res.Hits {
+		rounded, err := strconv.ParseFloat(hit.Sort[0], 64)
+		if err != nil {
+			t.Fatalf("expected a readable score, got %q", hit.Sort[0])
+		}
+		if rounded > hit.Score || hit.Score-rounded >= 0.2 {
+			t.Errorf("expected %s rounded down to 0.2, got %g", hit.ID, rounded)
+		}
+	}
+
+	// the same sort expressed in JSON
+	var sr SearchRequest
+	err = json.Unmarshal([]byte(`{"query":{"match":"bleve","field":"title"},`+
+		`"sort":[{"by":"score","desc":true,"precision":0.2},"-published"]}`), &sr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkOrder(sr.Sort, []string{"best", "new", "old"})
+
+	_, err = search.ParseSearchSortObj(map[string]interface{}{
+		"by":        "score",
+		"precision": -1.0,
+	})
+	if err == nil {
+		t.Errorf("expected error for negative precision")
+	}
+}
+
+func TestQueryStringAnalyzer(t *testing.T) {
+	tmpIndexPath := createTmpIndexPath(t)
+	defer cleanupTmpIndexPath(t, tmpIndexPath)
+
+	textMapping := mapping.NewTextFieldMapping()
+	textMapping.Analyzer = en.AnalyzerName
+	im := NewIndexMapping()
+	im.DefaultMapping.AddFieldMappingsAt("text", textMapping)
+
+	idx, err := New(tmpIndexPath, im)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err := idx.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	docs := map[string]map[string]interface{}{
+		"a": {"text": "she runs daily"},
+		"b": {"text": "a long run"},
+	}
+	for id, doc := range docs {
+		if err = idx.Index(id, doc); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	search := func(q *query.QueryStringQuery) []string {
+		sr := NewSearchRequest(q)
+		sr.SortBy([]string{"_id"})
+		res, err := idx.Search(sr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rv := make([]string, 0, len(res.Hits))
+		for _, hit := range res.Hits {
+			rv = append(rv, hit.ID)
+		}
+		return rv
+	}
+
+	// the field analyzer stems the query term
+	q := NewQueryStringQuery("text:running")
+	if got, want := search(q), []string{"a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	// the keyword analyzer searches the exact form
+	q = NewQueryStringQuery("text:running")
+	q.SetAnalyzer(keyword.Name)
+	if got, want := search(q), []string{}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	q = NewQueryStringQuery("text:running")
+	q.SetAnalyzer("does-not-exist")
+	_, err = idx.Search(NewSearchRequest(q))
+	if err == nil {
+		t.Errorf("expected error for unknown analyzer")
+	}
+}
+
+func TestNumericStatsFacet(t *testing.T) {
+	prices := map[string]float64{
+		"a": 10.5,
+		"b": 4,
+		"c": 25,
+		"d": 7.25,
+	}
+
+	idx1, err := NewMemOnly(NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx2, err := NewMemOnly(NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = idx1.Close()
+		_ = idx2.Close()
+	}()
+
+	for id, price := range prices {
+		idx := idx1
+		if id == "c" || id == "d" {
+			idx = idx2
+		}
+		err = idx.Index(id, map[string]interface{}{"type": "product", "price": price})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = idx1.Index("e", map[string]interface{}{"type": "product"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var sum float64
+	for _, price := range prices {
+		sum += price
+	}
+	expected := search.NumericStats{
+		Count: 4,
+		Min:   4,
+		Max:   25,
+		Sum:   sum,
+		Avg:   sum / 4,
+	}
+
+	for _, idx := range []Index{idx1, NewIndexAlias(idx1, idx2)} {
+		sr := NewSearchRequest(NewMatchQuery("product"))
+		sr.AddFacet("prices", NewStatsFacetRequest("price"))
+		res, err := idx.Search(sr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if idx == idx1 {
+			// only the documents of the first index
+			got := res.Facets["prices"]
+			if got.Stats == nil || got.Stats.Count != 2 || got.Stats.Avg != 7.25 {
+				t.Errorf("expected stats over 2 documents with avg 7.25, got %+v", got.Stats)
+			}
+			if got.Missing != 1 {
+				t.Errorf("expected 1 document missing a price, got %d", got.Missing)
+			}
+			continue
+		}
+		got := res.Facets["prices"].Stats
+		if got == nil || *got != expected {
+			t.Errorf("expected stats %+v, got %+v", expected, got)
+		}
+	}
+
+	fr := NewStatsFacetRequest("price")
+	fr.AddNumericRange("cheap", nil, &sum)
+	if err = fr.Validate(); err == nil {
+		t.Errorf("expected error combining stats and ranges")
+	}
+}
+
+func TestSearchHighlightFieldStyles(t *testing.T) {
+	tmpIndexPath := createTmpIndexPath(t)
+	defer cleanupTmpIndexPath(t, tmpIndexPath)
+
+	idx, err := New(tmpIndexPath, NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err := idx.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	err = idx.Index("doc", map[string]interface{}{
+		"title":   "bleve search",
+		"content": "full text search for go",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sr := NewSearchRequest(NewMatchQuery("search"))
+	sr.Highlight = NewHighlightWithStyle(html.Name)
+	sr.Highlight.SetFieldStyle("content", ansi.Name)
+	res, err := idx.Search(sr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Hits) != 1 {
+		t.Fatalf("expected 1 hit, got %d", len(res.Hits))
+	}
+
+	fragments := res.Hits[0].Fragments
+	if len(fragments["title"]) != 1 || !strings.Contains(fragments["title"][0], "<mark>search</mark>") {
+		t.Errorf("expected html markup in title, got %v", fragments["title"])
+	}
+	if len(fragments["content"]) != 1 || !strings.Contains(fragments["content"][0], "\x1b[43msearch") {
+		t.Errorf("expected ansi markup in content, got %q", fragments["content"])
+	}
+
+	sr.Highlight.SetFieldStyle("content", "does-not-exist")
+	_, err = idx.Search(sr)
+	if err == nil {
+		t.Errorf("expected error for unknown highlight style")
+	}
+}
+
+func TestSearchMatchedTerms(t *testing.T) {
+	idx1, err := NewMemOnly(NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx2, err := NewMemOnly(NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = idx1.Close()
+		_ = idx2.Close()
+	}()
+
+	err = idx1.Index("a", map[string]interface{}{"name": "the quick fox"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = idx2.Index("b", map[string]interface{}{"name": "quirk of fate"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	q := NewMatchQuery("quik")
+	q.SetField("name")
+	q.SetFuzziness(1)
+
+	sr := NewSearchRequest(q)
+	res, err := idx1.Search(sr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.MatchedTerms != nil {
+		t.Errorf("expected no matched terms without locations, got %v", res.MatchedTerms)
+	}
+
+	sr.IncludeLocations = true
+	res, err = idx1.Search(sr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string][]string{"name": {"quick"}}
+	if !reflect.DeepEqual(res.MatchedTerms, expected) {
+		t.Errorf("expected matched terms %v, got %v", expected, res.MatchedTerms)
+	}
+
+	res, err = NewIndexAlias(idx1, idx2).Search(sr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = map[string][]string{"name": {"quick", "quirk"}}
+	if !reflect.DeepEqual(res.MatchedTerms, expected) {
+		t.Errorf("expected matched terms %v, got %v", expected, res.MatchedTerms)
+	}
+}
+
+func TestFacetRangesSortByBound(t *testing.T) {
+	idx, err := NewMemOnly(NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = idx.Close()
+	}()
+
+	prices := []float64{5, 15, 15, 25, 25, 25, 35}
+	dates := []string{"2020-06-01T00:00:00Z", "2021-06-01T00:00:00Z", "2021-07-01T00:00:00Z", "2022-06-01T00:00:00Z"}
+	for i, price := range prices {
+		doc := map[string]interface{}{"type": "product", "price": price}
+		if i < len(dates) {
+			doc["date"] = dates[i]
+		}
+		err = idx.Index(strconv.Itoa(i), doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ten, twenty, thirty := 10.0, 20.0, 30.0
+	priceFacet := NewFacetRequest("price", 10)
+	priceFacet.SortByBound = true
+	priceFacet.AddNumericRange("high", &thirty, nil)
+	priceFacet.AddNumericRange("mid", &twenty, &thirty)
+	priceFacet.AddNumericRange("low", nil, &ten)
+	priceFacet.AddNumericRange("lowmid", &ten, &twenty)
+
+	y2021, y2022 := "2021-01-01T00:00:00Z", "2022-01-01T00:00:00Z"
+	dateFacet := NewFacetRequest("date", 10)
+	dateFacet.SortByBound = true
+	dateFacet.AddDateTimeRangeString("later", &y2022, nil)
+	dateFacet.AddDateTimeRangeString("2021", &y2021, &y2022)
+	dateFacet.AddDateTimeRangeString("earlier", nil, &y2021)
+
+	for _, idx := range []Index{idx, NewIndexAlias(idx)} {
+		sr := NewSearchRequest(NewMatchQuery("product"))
+		sr.AddFacet("prices", priceFacet)
+		sr.AddFacet("dates", dateFacet)
+		res, err := idx.Search(sr)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var got []string
+		for _, nr := range res.Facets["prices"].NumericRanges {
+			got = append(got, fmt.Sprintf("%s(%d)", nr.Name, nr.Count))
+		}
+		expected := []string{"low(1)", "lowmid(2)", "mid(3)", "high(1)"}
+		if !reflect.DeepEqual(got, expected) {
+			t.Errorf("expected numeric ranges %v, got %v", expected, got)
+		}
+
+		got = nil
+		for _, dr := range res.Facets["dates"].DateRanges {
+			got = append(got, fmt.Sprintf("%s(%d)", dr.Name, dr.Count))
+		}
+		expected = []string{"earlier(1)", "2021(2)", "later(1)"}
+		if !reflect.DeepEqual(got, expected) {
+			t.Errorf("expected date ranges %v, got %v", expected, got)
+		}
+	}
+}
+
+func TestSearchMatchedFields(t *testing.T) {
+	idx, err := NewMemOnly(NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = idx.Close()
+	}()
+
+	err = idx.Index("a", map[string]interface{}{
+		"title":   "release notes",
+		"content": "the search engine got faster",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	titleQuery := NewMatchQuery("engine")
+	titleQuery.SetField("title")
+	contentQuery := NewMatchQuery("engine")
+	contentQuery.SetField("content")
+
+	sr := NewSearchRequest(NewDisjunctionQuery(titleQuery, contentQuery))
+	sr.IncludeLocations = true
+	res, err := idx.Search(sr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Hits) != 1 {
+		t.Fatalf("expected 1 hit, got %d", len(res.Hits))
+	}
+	expected := []string{"content"}
+	if !reflect.DeepEqual(res.Hits[0].MatchedFields, expected) {
+		t.Errorf("expected matched fields %v, got %v", expected, res.Hits[0].MatchedFields)
+	}
+}
+
+func TestNgramAnalyzerInfixMatch(t *testing.T) {
+	im := NewIndexMapping()
+	err := im.AddNgramAnalyzer("infix", 3, 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nameMapping := NewTextFieldMapping()
+	nameInfixMapping := NewTextFieldMapping()
+	nameInfixMapping.Name = "name_infix"
+	nameInfixMapping.Analyzer = "infix"
+	im.DefaultMapping.AddFieldMappingsAt("name", nameMapping, nameInfixMapping)
+
+	idx, err := NewMemOnly(im)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = idx.Close()
+	}()
+
+	err = idx.Index("a", map[string]interface{}{"name": "PostgreSQL"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = idx.Index("b", map[string]interface{}{"name": "MySQL"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	q := NewMatchQuery("gres")
+	q.SetField("name")
+	res, err := idx.Search(NewSearchRequest(q))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Total != 0 {
+		t.Errorf("expected no match on the regular field, got %d", res.Total)
+	}
+
+	q.SetField("name_infix")
+	q.Analyzer = "simple"
+	res, err = idx.Search(NewSearchRequest(q))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Total != 1 || res.Hits[0].ID != "a" {
+		t.Errorf("expected infix match on 'a', got %v", res.Hits)
+	}
+
+	if err = im.AddNgramAnalyzer("invalid", 4, 2); err == nil {
+		t.Errorf("expected error for invalid n-gram lengths")
+	}
+}
+
+func TestSearchTieBreak(t *testing.T) {
+	idx, err := NewMemOnly(NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = idx.Close()
+	}()
+
+	ids := []string{"e", "b", "d", "a", "c"}
+	for _, id := range ids {
+		err = idx.Index(id, map[string]interface{}{"name": "same text"})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	q := NewMatchQuery("text")
+	q.SetField("name")
+
+	page := func(from int) []string {
+		sr := NewSearchRequestOptions(q, 2, from, false)
+		sr.TieBreak = true
+		res, err := idx.Search(sr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var rv []string
+		for _, hit := range res.Hits {
+			if len(hit.Sort) != 2 || hit.Sort[1] != hit.ID {
+				t.Fatalf("expected the sort to end with the id, got %v", hit.Sort)
+			}
+			rv = append(rv, hit.ID)
+		}
+		return rv
+	}
+
+	first := page(0)
+	if !reflect.DeepEqual(first, page(0)) {
+		t.Errorf("expected identical order over repeated searches")
+	}
+
+	var all []string
+	for from := 0; from < len(ids); from += 2 {
+		all = append(all, page(from)...)
+	}
+	expected := []string{"a", "b", "c", "d", "e"}
+	if !reflect.DeepEqual(all, expected) {
+		t.Errorf("expected pages to cover %v, got %v", expected, all)
+	}
+
+	// the caller's request must keep its own sort order
+	sr := NewSearchRequestOptions(q, 2, 0, true)
+	sr.TieBreak = true
+	for _, searcher := range []Index{idx, NewIndexAlias(idx)} {
+		res, err := searcher.Search(sr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(sr.Sort) != 1 {
+			t.Errorf("expected the request sort to be left unchanged, got %v", sr.Sort)
+		}
+		if res.Request != sr {
+			t.Errorf("expected the result to report the original request")
+		}
+	}
+}
+
+func TestSearchHighlightFragmentCaps(t *testing.T) {
+	idx, err := NewMemOnly(NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = idx.Close()
+	}()
+
+	for i := 0; i < 5; i++ {
+		err = idx.Index(fmt.Sprintf("doc-%d", i), map[string]interface{}{
+			"title":   "bleve search",
+			"summary": "a search library",
+			"body":    "full text search for go",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sr := NewSearchRequest(NewMatchQuery("search"))
+	sr.Highlight = NewHighlight()
+	res, err := idx.Search(sr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	countFragments := func(res *SearchResult) (int, int) {
+		var total, most int
+		for _, hit := range res.Hits {
+			var n int
+			for _, fragments := range hit.Fragments {
+				n += len(fragments)
+			}
+			total += n
+			if n > most {
+				most = n
+			}
+		}
+		return total, most
+	}
+	if total, _ := countFragments(res); total != 15 {
+		t.Fatalf("expected 15 fragments without caps, got %d", total)
+	}
+
+	sr.Highlight.MaxFragmentsPerHit = 2
+	sr.Highlight.MaxFragments = 7
+	res, err = idx.Search(sr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	total, most := countFragments(res)
+	if total != 7 {
+		t.Errorf("expected 7 fragments in the response, got %d", total)
+	}
+	if most != 2 {
+		t.Errorf("expected at most 2 fragments per hit, got %d", most)
+	}
+	if res.Hits[4].Fragments != nil {
+		t.Errorf("expected no fragments left for the last hit, got %v", res.Hits[4].Fragments)
+	}
+	if _, ok := res.Hits[0].Fragments["title"]; ok {
+		t.Errorf("expected the fragments of the first fields by name, got %v", res.Hits[0].Fragments)
+	}
+}
+
+func TestSoundexAnalyzerMatch(t *testing.T) {
+	im := NewIndexMapping()
+	err := im.AddSoundexAnalyzer("phonetic")
+	if err != nil {
+		t.Fatal(err)
+	}
+	nameMapping := NewTextFieldMapping()
+	namePhoneticMapping := NewTextFieldMapping()
+	namePhoneticMapping.Name = "name_phonetic"
+	namePhoneticMapping.Analyzer = "phonetic"
+	im.DefaultMapping.AddFieldMappingsAt("name", nameMapping, namePhoneticMapping)
+
+	idx, err := NewMemOnly(im)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = idx.Close()
+	}()
+
+	for id, name := range map[string]string{"a": "Smyth", "b": "Jones"} {
+		err = idx.Index(id, map[string]interface{}{"name": name})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	q := NewMatchQuery("Smith")
+	q.SetField("name")
+	res, err := idx.Search(NewSearchRequest(q))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Total != 0 {
+		t.Errorf("expected no match on the regular field, got %d", res.Total)
+	}
+
+	q.SetField("name_phonetic")
+	res, err = idx.Search(NewSearchRequest(q))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Total != 1 || res.Hits[0].ID != "a" {
+		t.Errorf("expected phonetic match on 'a', got %v", res.Hits)
+	}
+}
+
+func TestFacetSelections(t *testing.T) {
+	idx, err := NewMemOnly(NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = idx.Close()
+	}()
+
+	docs := map[string]map[string]interface{}{
+		"a": {"type": "book", "price": 5},
+		"b": {"type": "book", "price": 15},
+		"c": {"type": "movie", "price": 10},
+	}
+	for id, doc := range docs {
+		err = idx.Index(id, doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	search := func(mode string, selectType []string, selectPrice []string) *SearchResult {
+		sr := NewSearchRequest(NewMatchAllQuery())
+		sr.SortBy([]string{"_id"})
+		sr.Explain = true
+		sr.FacetMode = mode
+		typeFacet := NewFacetRequest("type", 10)
+		typeFacet.Select(selectType...)
+		sr.AddFacet("type", typeFacet)
+		cheap := 10.0
+		priceFacet := NewFacetRequest("price", 10)
+		priceFacet.AddNumericRange("cheap", nil, &cheap)
+		priceFacet.AddNumericRange("expensive", &cheap, nil)
+		priceFacet.Select(selectPrice...)
+		sr.AddFacet("price", priceFacet)
+		if err := sr.Validate(); err != nil {
+			t.Fatal(err)
+		}
+		res, err := idx.Search(sr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Request != sr {
+			t.Errorf("expected the result to report the original request")
+		}
+		return res
+	}
+	ids := func(res *SearchResult) []string {
+		var rv []string
+		for _, hit := range res.Hits {
+			rv = append(rv, hit.ID)
+		}
+		return rv
+	}
+	terms := func(res *SearchResult) map[string]int {
+		rv := make(map[string]int)
+		for _, term := range res.Facets["type"].Terms.Terms() {
+			rv[term.Term] = term.Count
+		}
+		return rv
+	}
+
+	res := search("", []string{"book"}, nil)
+	if !reflect.DeepEqual(ids(res), []string{"a", "b"}) {
+		t.Errorf("expected the books, got %v", ids(res))
+	}
+	if !reflect.DeepEqual(terms(res), map[string]int{"book": 2}) {
+		t.Errorf("expected facets over the selection, got %v", terms(res))
+	}
+
+	res = search(FacetModePreFilter, []string{"book"}, nil)
+	if !reflect.DeepEqual(ids(res), []string{"a", "b"}) {
+		t.Errorf("expected the books, got %v", ids(res))
+	}
+	if !reflect.DeepEqual(terms(res), map[string]int{"book": 2, "movie": 1}) {
+		t.Errorf("expected facets before the selection, got %v", terms(res))
+	}
+
+	res = search("", []string{"book", "movie"}, []string{"expensive"})
+	if !reflect.DeepEqual(ids(res), []string{"b", "c"}) {
+		t.Errorf("expected the expensive items, got %v", ids(res))
+	}
+
+	sr := NewSearchRequest(NewMatchAllQuery())
+	priceFacet := NewFacetRequest("price", 10)
+	zero := 0.0
+	priceFacet.AddNumericRange("any", &zero, nil)
+	priceFacet.Select("missing")
+	sr.AddFacet("price", priceFacet)
+	if err = sr.Validate(); err == nil {
+		t.Errorf("expected error selecting an unknown range")
+	}
+}
+
+func TestSearchResultNormalizeScores(t *testing.T) {
+	idx, err := NewMemOnly(NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = idx.Close()
+	}()
+
+	docs := map[string]string{
+		"a": "search search search",
+		"b": "search engine",
+		"c": "full text search for go, with many other words",
+	}
+	for id, body := range docs {
+		err = idx.Index(id, map[string]interface{}{"body": body})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	res, err := idx.Search(NewSearchRequest(NewMatchQuery("search")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Hits) != 3 {
+		t.Fatalf("expected 3 hits, got %d", len(res.Hits))
+	}
+	for _, hit := range res.Hits {
+		if hit.NormalizedScore != 0 {
+			t.Fatalf("expected no normalized scores by default, got %f", hit.NormalizedScore)
+		}
+	}
+
+	res.NormalizeScores()
+	if res.Hits[0].NormalizedScore != 1.0 {
+		t.Errorf("expected the top hit to score 1, got %f", res.Hits[0].NormalizedScore)
+	}
+	for _, hit := range res.Hits[1:] {
+		expected := hit.Score / res.Hits[0].Score
+		if math.Abs(hit.NormalizedScore-expected) > 1e-9 || hit.NormalizedScore >= 1 {
+			t.Errorf("expected normalized score %f for %s, got %f", expected, hit.ID, hit.NormalizedScore)
+		}
+	}
+}
+
+func TestSearchResultMarkRelevant(t *testing.T) {
+	idx, err := NewMemOnly(NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = idx.Close()
+	}()
+
+	docs := map[string]string{
+		"a": "search search search",
+		"b": "search engine",
+		"c": "full text search for go, with many other words",
+	}
+	for id, body := range docs {
+		err = idx.Index(id, map[string]interface{}{"body": body})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	res, err := idx.Search(NewSearchRequest(NewMatchQuery("search")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Hits) != 3 {
+		t.Fatalf("expected 3 hits, got %d", len(res.Hits))
+	}
+	for _, hit := range res.Hits {
+		if hit.Relevant != nil {
+			t.Fatalf("expected no relevant flag by default, got %t", *hit.Relevant)
+		}
+	}
+
+	threshold := res.Hits[1].Score
+	res.MarkRelevant(threshold)
+	if len(res.Hits) != 3 {
+		t.Fatalf("expected the hits below the threshold to be kept, got %d", len(res.Hits))
+	}
+	for _, hit := range res.Hits {
+		expected := hit.Score >= threshold
+		if hit.Relevant == nil || *hit.Relevant != expected {
+			t.Errorf("expected relevant %t for %s with score %f", expected, hit.ID, hit.Score)
+		}
+	}
+	if !*res.Hits[0].Relevant || *res.Hits[2].Relevant {
+		t.Errorf("expected the top hit relevant and the last one not")
+	}
+}
+
+func TestDiagnoseQuery(t *testing.T) {
+	idx, err := NewMemOnly(NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = idx.Close()
+	}()
+
+	docs := map[string]map[string]interface{}{
+		"a": {"title": "go search library", "year": 2014},
+		"b": {"title": "rust search library", "year": 2020},
+	}
+	for id, doc := range docs {
+		err = idx.Index(id, doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	search := NewMatchQuery("search")
+	search.SetField("title")
+	missing := NewTermQuery("python")
+	missing.SetField("title")
+	min := 2010.0
+	year := NewNumericRangeQuery(&min, nil)
+	year.SetField("year")
+	q := NewBooleanQuery()
+	q.AddMust(search, missing, year)
+
+	res, err := idx.Search(NewSearchRequest(q))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Total != 0 {
+		t.Fatalf("expected no results, got %d", res.Total)
+	}
+
+	diagnose := func(req *SearchRequest) ([]*ClauseDiagnosis, []string) {
+		diagnosis, err := DiagnoseQuery(context.Background(), idx, req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var rv []string
+		for _, d := range diagnosis {
+			rv = append(rv, fmt.Sprintf("%s:%s=%d", d.Part, d.Path, d.Matches))
+		}
+		return diagnosis, rv
+	}
+
+	diagnosis, got := diagnose(NewSearchRequest(q))
+	expected := []string{
+		"query:must.conjuncts[0]=2",
+		"query:must.conjuncts[1]=0",
+		"query:must.conjuncts[2]=2",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected diagnosis %v, got %v", expected, got)
+	}
+	if diagnosis[1].Clause != missing {
+		t.Errorf("expected the culprit to be the term query, got %v", diagnosis[1].Clause)
+	}
+
+	// a filter eliminating all the results is diagnosed as well
+	future := 2030.0
+	recent := NewNumericRangeQuery(&future, nil)
+	recent.SetField("year")
+	req := NewSearchRequest(search)
+	req.Filter = recent
+	res, err = idx.Search(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Total != 0 {
+		t.Fatalf("expected no results, got %d", res.Total)
+	}
+	_, got = diagnose(req)
+	expected = []string{"query:=2", "filter:=0"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected diagnosis %v, got %v", expected, got)
+	}
+}
+
+func TestSearchUnstoredFieldWarnings(t *testing.T) {
+	im := NewIndexMapping()
+	titleMapping := NewTextFieldMapping()
+	bodyMapping := NewTextFieldMapping()
+	bodyMapping.Store = false
+	im.DefaultMapping.AddFieldMappingsAt("title", titleMapping)
+	im.DefaultMapping.AddFieldMappingsAt("body", bodyMapping)
+
+	idx, err := NewMemOnly(im)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = idx.Close()
+	}()
+
+	err = idx.Index("a", map[string]interface{}{
+		"title": "release notes",
+		"body":  "the search engine got faster",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sr := NewSearchRequest(NewMatchAllQuery())
+	sr.Fields = []string{"title", "body"}
+	res, err := idx.Search(sr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Warnings != nil {
+		t.Errorf("expected no warnings unless requested, got %v", res.Warnings)
+	}
+
+	sr.IncludeWarnings = true
+	res, err = idx.Search(sr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Hits[0].Fields["title"] != "release notes" {
+		t.Errorf("expected the stored title, got %v", res.Hits[0].Fields)
+	}
+	if _, ok := res.Hits[0].Fields["body"]; ok {
+		t.Errorf("expected no value for the unstored body, got %v", res.Hits[0].Fields)
+	}
+	expected := []string{"field 'body' is not stored, its values cannot be returned"}
+	if !reflect.DeepEqual(res.Warnings, expected) {
+		t.Errorf("expected warnings %v, got %v", expected, res.Warnings)
+	}
+
+	sr.Fields = []string{"*"}
+	res, err = idx.Search(sr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Warnings != nil {
+		t.Errorf("expected no warnings for a pattern, got %v", res.Warnings)
+	}
+}
+
+func TestSearchScoreNoneSortByField(t *testing.T) {
+	idx, err := NewMemOnly(NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = idx.Close()
+	}()
+
+	for i, body := range []string{"search search", "search engine", "engine", "search"} {
+		err = idx.Index(strconv.Itoa(i), map[string]interface{}{
+			"body": body,
+			"rank": 10 - i,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	q := NewMatchQuery("search")
+	q.SetField("body")
+	sr := NewSearchRequest(q)
+	sr.Score = ScoreNone
+	sr.SortBy([]string{"rank"})
+	res, err := idx.Search(sr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, hit := range res.Hits {
+		if hit.Score != 0 {
+			t.Errorf("expected no score for %s, got %f", hit.ID, hit.Score)
+		}
+		ids = append(ids, hit.ID)
+	}
+	expected := []string{"3", "1", "0"}
+	if res.Total != 3 || !reflect.DeepEqual(ids, expected) {
+		t.Errorf("expected hits %v sorted by rank, got %v", expected, ids)
+	}
+}
+
+func TestSearchResultRenameFields(t *testing.T) {
+	idx, err := NewMemOnly(NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = idx.Close()
+	}()
+
+	err = idx.Index("a", map[string]interface{}{
+		"content": "the search engine got faster",
+		"title":   "release notes",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sr := NewSearchRequest(NewMatchAllQuery())
+	sr.Fields = []string{"*"}
+	res, err := idx.Search(sr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = res.RenameFields(map[string]string{"content": "title"})
+	if err == nil {
+		t.Errorf("expected error renaming onto an existing field")
+	}
+	if _, ok := res.Hits[0].Fields["content"]; !ok {
+		t.Errorf("expected fields unchanged after a failed rename, got %v", res.Hits[0].Fields)
+	}
+
+	err = res.RenameFields(map[string]string{"content": "text"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{
+		"text":  "the search engine got faster",
+		"title": "release notes",
+	}
+	if !reflect.DeepEqual(res.Hits[0].Fields, expected) {
+		t.Errorf("expected fields %v, got %v", expected, res.Hits[0].Fields)
+	}
+}
+
+func TestHistogramFacet(t *testing.T) {
+	prices := map[string]float64{
+		"a": 3,
+		"b": 9.99,
+		"c": 10,
+		"d": 15.5,
+		"e": 42,
+	}
+
+	idx1, err := NewMemOnly(NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx2, err := NewMemOnly(NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = idx1.Close()
+		_ = idx2.Close()
+	}()
+
+	for id, price := range prices {
+		idx := idx1
+		if id == "c" || id == "e" {
+			idx = idx2
+		}
+		err = idx.Index(id, map[string]interface{}{"type": "product", "price": price})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expected := search.HistogramBuckets{
+		{Min: 0, Max: 10, Count: 2},
+		{Min: 10, Max: 20, Count: 2},
+		{Min: 40, Max: 50, Count: 1},
+	}
+
+	// the buckets of both indexes are merged by the alias
+	sr := NewSearchRequest(NewMatchQuery("product"))
+	sr.AddFacet("prices", NewHistogramFacetRequest("price", 10))
+	res, err := NewIndexAlias(idx1, idx2).Search(sr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := res.Facets["prices"]
+	if got.Total != 5 {
+		t.Errorf("expected 5 values, got %d", got.Total)
+	}
+	if !reflect.DeepEqual(got.Histogram, expected) {
+		t.Errorf("expected buckets %v, got %v", expected, got.Histogram)
+	}
+
+	sr = NewSearchRequest(NewMatchQuery("product"))
+	sr.AddFacet("prices", NewHistogramFacetRequest("price", -1))
+	if err = sr.Validate(); err == nil {
+		t.Errorf("expected error for negative interval")
+	}
+}
+
+func TestFuzzyTranspositions(t *testing.T) {
+	// keep the stop word "the"
+	im := NewIndexMapping()
+	im.DefaultAnalyzer = simple.Name
+	idx, err := NewMemOnly(im)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = idx.Close()
+	}()
+
+	err = idx.Index("a", map[string]interface{}{"body": "the quick fox"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fuzzy := NewFuzzyQuery("teh")
+	fuzzy.SetField("body")
+	match := NewMatchQuery("teh")
+	match.SetField("body")
+	match.SetFuzziness(1)
+
+	for _, q := range []interface {
+		query.Query
+		SetTranspositions(bool)
+	}{fuzzy, match} {
+		res, err := idx.Search(NewSearchRequest(q))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Total != 0 {
+			t.Errorf("expected no match for %T without transpositions, got %d", q, res.Total)
+		}
+
+		q.SetTranspositions(true)
+		res, err = idx.Search(NewSearchRequest(q))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Total != 1 {
+			t.Errorf("expected a match for %T with transpositions, got %d", q, res.Total)
+		}
+	}
+}
+
+func TestDateHistogramFacet(t *testing.T) {
+	idx, err := NewMemOnly(NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = idx.Close()
+	}()
+
+	updated := map[string]string{
+		"a": "2024-03-01T08:00:00Z",
+		"b": "2024-03-01T23:30:00Z",
+		"c": "2024-03-02T12:00:00Z",
+		"d": "2024-03-03T01:00:00Z",
+		"e": "2024-03-03T22:15:00Z",
+		"f": "2024-03-03T23:59:59Z",
+	}
+	for id, date := range updated {
+		err = idx.Index(id, map[string]interface{}{"type": "event", "updated": date})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	day := func(date string, loc *time.Location) time.Time {
+		t, err := time.ParseInLocation("2006-01-02", date, loc)
+		if err != nil {
+			panic(err)
+		}
+		return t
+	}
+	counts := func(buckets search.DateHistogramBuckets) map[time.Time]int {
+		rv := make(map[time.Time]int, len(buckets))
+		for _, b := range buckets {
+			if !b.End.Equal(b.Start.AddDate(0, 0, 1)) {
+				t.Errorf("expected a daily bucket, got %v to %v", b.Start, b.End)
+			}
+			rv[b.Start.UTC()] = b.Count
+		}
+		return rv
+	}
+
+	sr := NewSearchRequest(NewMatchQuery("event"))
+	sr.AddFacet("days", NewDateHistogramFacetRequest("updated", "day"))
+	res, err := idx.Search(sr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[time.Time]int{
+		day("2024-03-01", time.UTC): 2,
+		day("2024-03-02", time.UTC): 1,
+		day("2024-03-03", time.UTC): 3,
+	}
+	if got := counts(res.Facets["days"].DateHistogram); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected buckets %v, got %v", expected, got)
+	}
+
+	// two hours ahead, the late evening events move to the next day
+	east := time.FixedZone("+02:00", 2*60*60)
+	dates := NewDateHistogramFacetRequest("updated", "day")
+	dates.TimeZone = "+02:00"
+	sr = NewSearchRequest(NewMatchQuery("event"))
+	sr.AddFacet("days", dates)
+	res, err = idx.Search(sr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = map[time.Time]int{
+		day("2024-03-01", east).UTC(): 1,
+		day("2024-03-02", east).UTC(): 2,
+		day("2024-03-03", east).UTC(): 1,
+		day("2024-03-04", east).UTC(): 2,
+	}
+	if got := counts(res.Facets["days"].DateHistogram); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected buckets %v, got %v", expected, got)
+	}
+
+	dates.CalendarInterval = "fortnight"
+	if err = sr.Validate(); err == nil {
+		t.Errorf("expected error for unknown calendar interval")
+	}
+}
+
+func TestSearchHighlightOffsets(t *testing.T) {
+	idx, err := NewMemOnly(NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = idx.Close()
+	}()
+
+	content := "search engines index; a searcher searches"
+	err = idx.Index("a", map[string]interface{}{
+		"content": content,
+		"title":   "research",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	q := NewPrefixQuery("search")
+	q.SetField("content")
+	sr := NewSearchRequest(q)
+	sr.IncludeLocations = true
+	res, err := idx.Search(sr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Hits) != 1 {
+		t.Fatalf("expected 1 hit, got %d", len(res.Hits))
+	}
+
+	expected := map[string][]search.TermOffset{
+		"content": {
+			{Term: "search", Start: 0, End: 6},
+			{Term: "searcher", Start: 24, End: 32},
+			{Term: "searches", Start: 33, End: 41},
+		},
+	}
+	got := res.Hits[0].Highlights
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected highlights %v, got %v", expected, got)
+	}
+	for _, offset := range got["content"] {
+		if content[offset.Start:offset.End] != offset.Term {
+			t.Errorf("expected offsets of '%s', got '%s'", offset.Term, content[offset.Start:offset.End])
+		}
+	}
+}
+
+func TestSearchResultRerankByFreshness(t *testing.T) {
+	idx, err := NewMemOnly(NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = idx.Close()
+	}()
+
+	// the more relevant documents are the older ones
+	docs := []struct {
+		id      string
+		content string
+		updated string
+	}{
+		{"old", "bleve bleve bleve bleve search", "2020-01-01T00:00:00Z"},
+		{"older", "bleve bleve bleve search", "2021-01-01T00:00:00Z"},
+		{"recent", "bleve bleve search", "2023-01-01T00:00:00Z"},
+		{"newest", "bleve search", "2024-01-01T00:00:00Z"},
+	}
+	for _, doc := range docs {
+		err = idx.Index(doc.id, map[string]interface{}{
+			"content": doc.content,
+			"updated": doc.updated,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rank := func(res *SearchResult, id string) int {
+		for i, hit := range res.Hits {
+			if hit.ID == id {
+				return i
+			}
+		}
+		t.Fatalf("hit %s not found", id)
+		return -1
+	}
+
+	lastRank := len(docs)
+	for _, freshness := range []float64{0, 0.25, 0.5, 0.75, 1} {
+		sr := NewSearchRequest(NewMatchQuery("bleve"))
+		sr.Fields = []string{"updated"}
+		res, err := idx.Search(sr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = res.RerankByFreshness("updated", freshness)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r := rank(res, "newest")
+		if r > lastRank {
+			t.Errorf("freshness %g: expected newest document at rank %d or better, got %d", freshness, lastRank, r)
+		}
+		lastRank = r
+		if freshness == 0 && r != len(docs)-1 {
+			t.Errorf("expected newest document last by relevance, got rank %d", r)
+		}
+		if freshness == 1 && r != 0 {
+			t.Errorf("expected newest document first by recency, got rank %d", r)
+		}
+	}
+
+	err = (&SearchResult{}).RerankByFreshness("updated", 1.5)
+	if err == nil {
+		t.Errorf("expected error for freshness above 1")
+	}
+}
+
+func TestSearchHighlightTags(t *testing.T) {
+	idx, err := NewMemOnly(NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = idx.Close()
+	}()
+
+	err = idx.Index("a", map[string]interface{}{
+		"title":   "the <b>fastest</b> search engine",
+		"content": "a search engine",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sr := NewSearchRequest(NewMatchQuery("search"))
+	sr.Highlight = NewHighlight()
+	sr.Highlight.SetTags(`<em class="hl">`, "</em>")
+	sr.Highlight.SetFieldStyle("content", "ansi")
+	res, err := idx.Search(sr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Hits) != 1 {
+		t.Fatalf("expected 1 hit, got %d", len(res.Hits))
+	}
+
+	expected := search.FieldFragmentMap{
+		"title":   {`the &lt;b&gt;fastest&lt;/b&gt; <em class="hl">search</em> engine`},
+		"content": {`a <em class="hl">search</em> engine`},
+	}
+	if !reflect.DeepEqual(res.Hits[0].Fragments, expected) {
+		t.Errorf("expected fragments %v, got %v", expected, res.Hits[0].Fragments)
+	}
+
+	// the tags of one request do not leak into the next
+	sr.Highlight = NewHighlight()
+	res, err = idx.Search(sr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := res.Hits[0].Fragments["content"][0]; got != "a <mark>search</mark> engine" {
+		t.Errorf("expected default tags, got %s", got)
+	}
+}
+
+func TestSearchResultPagination(t *testing.T) {
+	tests := []struct {
+		total    uint64
+		from     int
+		size     int
+		expected Pagination
+	}{
+		{
+			// exact multiple, last page
+			total:    30,
+			from:     20,
+			size:     10,
+			expected: Pagination{Page: 3, PageSize: 10, TotalPages: 3, HasNext: false, HasPrev: true},
+		},
+		{
+			// partial last page
+			total:    25,
+			from:     20,
+			size:     10,
+			expected: Pagination{Page: 3, PageSize: 10, TotalPages: 3, HasNext: false, HasPrev: true},
+		},
+		{
+			total:    25,
+			from:     0,
+			size:     10,
+			expected: Pagination{Page: 1, PageSize: 10, TotalPages: 3, HasNext: true, HasPrev: false},
+		},
+		{
+			// from between pages
+			total:    25,
+			from:     15,
+			size:     10,
+			expected: Pagination{Page: 2, PageSize: 10, TotalPages: 3, HasNext: false, HasPrev: true},
+		},
+		{
+			total:    0,
+			from:     0,
+			size:     10,
+			expected: Pagination{Page: 1, PageSize: 10, TotalPages: 0, HasNext: false, HasPrev: false},
+		},
+		{
+			total:    25,
+			from:     0,
+			size:     0,
+			expected: Pagination{Page: 0, PageSize: 0, TotalPages: 0, HasNext: true, HasPrev: false},
+		},
+	}
+
+	for _, test := range tests {
+		sr := &SearchResult{Total: test.total}
+		got := sr.Pagination(&SearchRequest{From: test.from, Size: test.size})
+		if got == nil || *got != test.expected {
+			t.Errorf("total %d from %d size %d: expected %+v, got %+v",
+				test.total, test.from, test.size, test.expected, got)
+		}
+	}
+}
+
+func TestProfileQuery(t *testing.T) {
+	idx, err := NewMemOnly(NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = idx.Close()
+	}()
+
+	docs := map[string]map[string]interface{}{
+		"a": {"title": "go search library", "year": 2014},
+		"b": {"title": "rust search library", "year": 2020},
+		"c": {"title": "python web framework", "year": 2005},
+	}
+	for id, doc := range docs {
+		err = idx.Index(id, doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	search := NewMatchQuery("search")
+	search.SetField("title")
+	min := 2010.0
+	year := NewNumericRangeQuery(&min, nil)
+	year.SetField("year")
+	library := NewTermQuery("library")
+	library.SetField("title")
+	rust := NewTermQuery("rust")
+	rust.SetField("title")
+	q := NewBooleanQuery()
+	q.AddMust(search, year)
+	q.AddShould(library)
+	q.AddMustNot(rust)
+
+	c := NewDocIDQuery([]string{"c"})
+	notC := NewBooleanQuery()
+	notC.AddMustNot(c)
+	req := NewSearchRequest(q)
+	req.Filter = notC
+
+	profile, err := ProfileQuery(context.Background(), idx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile.Took <= 0 {
+		t.Errorf("expected the query to be timed, got %v", profile.Took)
+	}
+	var got []string
+	for _, clause := range profile.Clauses {
+		got = append(got, fmt.Sprintf("%s:%s=%d", clause.Part, clause.Path, clause.Matches))
+		if clause.Took <= 0 {
+			t.Errorf("expected clause %s to be timed, got %v", clause.Path, clause.Took)
+		}
+	}
+	expected := []string{
+		"query:must.conjuncts[0]=2",
+		"query:must.conjuncts[1]=2",
+		"query:should.disjuncts[0]=2",
+		"query:must_not.disjuncts[0]=1",
+		"filter:must_not.disjuncts[0]=1",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected clauses %v, got %v", expected, got)
+	}
+
+	_, err = ProfileQuery(context.Background(), idx, &SearchRequest{})
+	if err == nil {
+		t.Errorf("expected an error profiling a request without query or filter")
+	}
+}
+
+func TestFacetMissingAndOther(t *testing.T) {
+	idx, err := NewMemOnly(NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err := idx.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	docs := map[string]map[string]interface{}{
+		"a": {"type": "product", "category": "books", "price": 5.0},
+		"b": {"type": "product", "category": "music", "price": 15.0},
+		"c": {"type": "product", "price": 150.0},
+		"d": {"type": "product"},
+	}
+	for id, doc := range docs {
+		err = idx.Index(id, doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ten, hundred := 10.0, 100.0
+	prices := NewFacetRequest("price", 10)
+	prices.AddNumericRange("cheap", nil, &ten)
+	prices.AddNumericRange("moderate", &ten, &hundred)
+
+	sr := NewSearchRequest(NewMatchQuery("product"))
+	sr.AddFacet("categories", NewFacetRequest("category", 10))
+	sr.AddFacet("prices", prices)
+	res, err := idx.Search(sr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	categories := res.Facets["categories"]
+	if categories.Missing != 2 {
+		t.Errorf("expected 2 documents missing a category, got %d", categories.Missing)
+	}
+	if categories.Total != 2 {
+		t.Errorf("expected 2 categorized documents, got %d", categories.Total)
+	}
+
+	priced := res.Facets["prices"]
+	if priced.Missing != 1 {
+		t.Errorf("expected 1 document missing a price, got %d", priced.Missing)
+	}
+	// the price of 150 falls outside all of the ranges
+	if priced.Other != 1 {
+		t.Errorf("expected 1 price outside the ranges, got %d", priced.Other)
+	}
+	if priced.Total != 3 {
+		t.Errorf("expected 3 prices, got %d", priced.Total)
+	}
+	if len(priced.NumericRanges) != 2 {
+		t.Fatalf("expected 2 numeric ranges, got %d", len(priced.NumericRanges))
+	}
+	for _, nr := range priced.NumericRanges {
+		if nr.Count != 1 {
+			t.Errorf("expected range %s to count 1, got %d", nr.Name, nr.Count)
+		}
+	}
+}
+
+func TestSearchResultBoostByField(t *testing.T) {
+	// the views are read from the doc values, they need not be stored
+	im := NewIndexMapping()
+	im.StoreDynamic = false
+	idx, err := NewMemOnly(im)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = idx.Close()
+	}()
+
+	// the documents are equally relevant, only their views differ
+	views := map[string]float64{
+		"a": 10,
+		"b": 1000,
+		"c": 100,
+	}
+	for id, v := range views {
+		err = idx.Index(id, map[string]interface{}{
+			"content": "bleve search",
+			"views":   v,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = idx.Index("d", map[string]interface{}{
+		"content": "bleve search",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, modifier := range []string{BoostModifierLinear, BoostModifierLog, BoostModifierSqrt} {
+		q := NewMatchQuery("bleve")
+		q.SetField("content")
+		res, err := idx.Search(NewSearchRequest(q))
+		if err != nil {
+			t.Fatal(err)
+		}
+		score := res.Hits[0].Score
+		err = res.BoostByField(idx, "views", modifier, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ids []string
+		for _, hit := range res.Hits {
+			ids = append(ids, hit.ID)
+		}
+		expected := []string{"b", "c", "a", "d"}
+		if !reflect.DeepEqual(ids, expected) {
+			t.Errorf("%s: expected order %v, got %v", modifier, expected, ids)
+		}
+		if res.MaxScore != res.Hits[0].Score {
+			t.Errorf("%s: expected max score %f, got %f", modifier, res.Hits[0].Score, res.MaxScore)
+		}
+		// the hit without views keeps its score
+		if res.Hits[3].Score != score {
+			t.Errorf("%s: expected unchanged score %f without views, got %f", modifier, score, res.Hits[3].Score)
+		}
+		if modifier == BoostModifierLinear && res.Hits[0].Score != score*2001 {
+			t.Errorf("expected linear score %f, got %f", score*2001, res.Hits[0].Score)
+		}
+		if res.Hits[0].Fields != nil {
+			t.Errorf("%s: expected no fields returned, got %v", modifier, res.Hits[0].Fields)
+		}
+	}
+
+	err = (&SearchResult{}).BoostByField(idx, "views", "square", 1)
+	if err == nil {
+		t.Errorf("expected error for unknown modifier")
+	}
+	err = (&SearchResult{}).BoostByField(idx, "views", BoostModifierLog, 0)
+	if err == nil {
+		t.Errorf("expected error for zero factor")
+	}
+}
+
+func TestBooleanQueryShouldBoostsWithoutFiltering(t *testing.T) {
+	idx, err := NewMemOnly(NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = idx.Close()
+	}()
+
+	docs := map[string]string{
+		"a": "bleve search engine",
+		"b": "bleve search library",
+		"c": "bleve indexing",
+		"d": "lucene search engine",
+	}
+	for id, content := range docs {
+		err = idx.Index(id, map[string]interface{}{"content": content})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	term := func(t string) *query.TermQuery {
+		q := NewTermQuery(t)
+		q.SetField("content")
+		return q
+	}
+	hitIDs := func(q query.Query) []string {
+		res, err := idx.Search(NewSearchRequest(q))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ids []string
+		for _, hit := range res.Hits {
+			ids = append(ids, hit.ID)
+		}
+		return ids
+	}
+
+	// should clauses only boost the documents satisfying the must
+	// clauses, setting the minimum before adding them is allowed
+	bq := NewBooleanQuery()
+	bq.SetMinShould(0)
+	bq.AddMust(term("bleve"))
+	bq.AddShould(term("engine"))
+	ids := hitIDs(bq)
+	if len(ids) != 3 || ids[0] != "a" {
+		t.Errorf("expected all 3 bleve documents with a first, got %v", ids)
+	}
+
+	// a minimum of 1 makes the should clauses filter
+	bq.SetMinShould(1)
+	ids = hitIDs(bq)
+	if !reflect.DeepEqual(ids, []string{"a"}) {
+		t.Errorf("expected only a to satisfy the should clause, got %v", ids)
+	}
+
+	// without must clauses, one should clause has to be satisfied
+	bq = NewBooleanQuery()
+	bq.AddShould(term("engine"), term("library"))
+	bq.AddMustNot(term("lucene"))
+	ids = hitIDs(bq)
+	sort.Strings(ids)
+	if !reflect.DeepEqual(ids, []string{"a", "b"}) {
+		t.Errorf("expected a and b to satisfy the should clauses, got %v", ids)
+	}
+}
+
+func TestSearchResultComputeChecksums(t *testing.T) {
+	idx, err := NewMemOnly(NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = idx.Close()
+	}()
+
+	doc := map[string]interface{}{
+		"title": "release notes",
+		"tags":  []interface{}{"bleve", "search"},
+		"views": 42.0,
+	}
+	for _, id := range []string{"a", "b"} {
+		err = idx.Index(id, doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	checksums := func(q query.Query) map[string]string {
+		sr := NewSearchRequest(q)
+		sr.Fields = []string{"*"}
+		res, err := idx.Search(sr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = res.ComputeChecksums()
+		if err != nil {
+			t.Fatal(err)
+		}
+		rv := make(map[string]string, len(res.Hits))
+		for _, hit := range res.Hits {
+			if hit.Checksum == "" {
+				t.Fatalf("expected a checksum for hit %s", hit.ID)
+			}
+			rv[hit.ID] = hit.Checksum
+		}
+		return rv
+	}
+
+	first := checksums(NewMatchAllQuery())
+	if first["a"] != first["b"] {
+		t.Errorf("expected equal documents to have equal checksums, got %s and %s", first["a"], first["b"])
+	}
+	second := checksums(NewMatchQuery("release"))
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("expected checksums to be stable across queries, got %v and %v", first, second)
+	}
+
+	doc["views"] = 43.0
+	err = idx.Index("b", doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	third := checksums(NewMatchAllQuery())
+	if third["a"] != first["a"] {
+		t.Errorf("expected unchanged document to keep its checksum, got %s and %s", first["a"], third["a"])
+	}
+	if third["b"] == first["b"] {
+		t.Errorf("expected changed document to change its checksum")
+	}
+}
+
+func TestSearchRequestRefine(t *testing.T) {
+	idx, err := NewMemOnly(NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = idx.Close()
+	}()
+
+	docs := map[string]string{
+		"a": "go programming language",
+		"b": "go concurrency patterns",
+		"c": "rust programming language",
+		"d": "go programming patterns",
+	}
+	for id, title := range docs {
+		err = idx.Index(id, map[string]interface{}{"title": title})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	match := func(text string) *query.MatchQuery {
+		q := NewMatchQuery(text)
+		q.SetField("title")
+		return q
+	}
+	hits := func(sr *SearchRequest) map[string]float64 {
+		res, err := idx.Search(sr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rv := make(map[string]float64, len(res.Hits))
+		for _, hit := range res.Hits {
+			rv[hit.ID] = hit.Score
+		}
+		return rv
+	}
+
+	broad := NewSearchRequest(match("go"))
+	broadHits := hits(broad)
+	if len(broadHits) != 3 {
+		t.Fatalf("expected 3 hits for the broad query, got %v", broadHits)
+	}
+
+	// the refinement is scored alone, within the broad hits
+	refinement := match("programming")
+	refinedHits := hits(broad.Refine(refinement))
+	if len(refinedHits) != 2 {
+		t.Fatalf("expected 2 refined hits, got %v", refinedHits)
+	}
+	for id := range refinedHits {
+		if _, ok := broadHits[id]; !ok {
+			t.Errorf("expected refined hit %s to be among the broad hits", id)
+		}
+	}
+	alone := hits(NewSearchRequest(refinement))
+	for id, score := range refinedHits {
+		if math.Abs(score-alone[id]) > 1e-9 {
+			t.Errorf("expected hit %s scored by the refinement alone, got %f, want %f", id, score, alone[id])
+		}
+	}
+
+	// refinements can be chained
+	chained := hits(broad.Refine(refinement).Refine(match("patterns")))
+	if len(chained) != 1 || chained["d"] == 0 {
+		t.Errorf("expected only hit d, got %v", chained)
+	}
+	if broad.Query == nil || broad.Filter != nil {
+		t.Errorf("expected the broad request to be left unchanged")
+	}
+}
+
+func TestSearchResultTruncateFields(t *testing.T) {
+	idx, err := NewMemOnly(NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = idx.Close()
+	}()
+
+	err = idx.Index("a", map[string]interface{}{
+		"title": "short",
+		"body":  "a rather long body of text about bleve",
+		"tags":  []interface{}{"search", "full-text"},
+		"views": 1234.0,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sr := NewSearchRequest(NewMatchQuery("bleve"))
+	sr.Fields = []string{"*"}
+	sr.Highlight = NewHighlight()
+	sr.Highlight.AddField("body")
+	res, err := idx.Search(sr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Hits) != 1 {
+		t.Fatalf("expected 1 hit, got %d", len(res.Hits))
+	}
+	fragments := res.Hits[0].Fragments["body"]
+
+	res.TruncateFields(map[string]int{
+		"title": 10,
+		"body":  10,
+		"tags":  6,
+		"views": 2,
+	})
+	expected := map[string]interface{}{
+		"title": "short",
+		"body":  "a rather …",
+		"tags":  []interface{}{"search", "full-…"},
+		"views": 1234.0,
+	}
+	if !reflect.DeepEqual(res.Hits[0].Fields, expected) {
+		t.Errorf("expected fields %v, got %v", expected, res.Hits[0].Fields)
+	}
+	if !reflect.DeepEqual(res.Hits[0].Fragments["body"], fragments) {
+		t.Errorf("expected fragments to be unchanged, got %v", res.Hits[0].Fragments["body"])
+	}
+}
+
+func TestSearchAnalyzerMismatchWarnings(t *testing.T) {
+	im := NewIndexMapping()
+	skuMapping := NewKeywordFieldMapping()
+	im.DefaultMapping.AddFieldMappingsAt("sku", skuMapping)
+
+	idx, err := NewMemOnly(im)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = idx.Close()
+	}()
+
+	err = idx.Index("a", map[string]interface{}{
+		"sku":   "AB-123 Blue",
+		"title": "blue widget",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the standard analyzer lowercases and splits the keyword
+	forced := NewMatchQuery("AB-123 Blue")
+	forced.SetField("sku")
+	forced.Analyzer = "standard"
+	search := func(q query.Query) (*SearchResult, error) {
+		req := NewSearchRequest(q)
+		req.IncludeWarnings = true
+		return idx.Search(req)
+	}
+	res, err := search(forced)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Total != 0 {
+		t.Errorf("expected no hits with the mismatched analyzer, got %d", res.Total)
+	}
+	expected := []string{"field 'sku' is indexed with analyzer 'keyword' " +
+		"but queried with analyzer 'standard'"}
+	if !reflect.DeepEqual(res.Warnings, expected) {
+		t.Errorf("expected warnings %v, got %v", expected, res.Warnings)
+	}
+
+	// clauses of compound queries are checked too
+	phrase := NewMatchPhraseQuery("blue widget")
+	phrase.SetField("title")
+	phrase.Analyzer = "keyword"
+	res, err = search(NewDisjunctionQuery(forced, phrase))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = append(expected, "field 'title' is indexed with analyzer 'standard' "+
+		"but queried with analyzer 'keyword'")
+	if !reflect.DeepEqual(res.Warnings, expected) {
+		t.Errorf("expected warnings %v, got %v", expected, res.Warnings)
+	}
+
+	// the analyzer of the field itself does not warn
+	matching := NewMatchQuery("AB-123 Blue")
+	matching.SetField("sku")
+	matching.Analyzer = "keyword"
+	res, err = search(matching)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Total != 1 {
+		t.Errorf("expected 1 hit with the field analyzer, got %d", res.Total)
+	}
+	if res.Warnings != nil {
+		t.Errorf("expected no warnings, got %v", res.Warnings)
+	}
+
+	// the clauses of query strings are checked as parsed for the search
+	qs := NewQueryStringQuery("sku:blue")
+	qs.SetAnalyzer("standard")
+	res, err = search(qs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(res.Warnings, expected[:1]) {
+		t.Errorf("expected warnings %v, got %v", expected[:1], res.Warnings)
+	}
+
+	// a query string failing to parse fails the search
+	_, err = search(NewQueryStringQuery(`sku:"blue`))
+	if err == nil {
+		t.Errorf("expected an error for an unparsable query string")
+	}
+
+	// no warnings unless requested
+	res, err = idx.Search(NewSearchRequest(forced))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Warnings != nil {
+		t.Errorf("expected no warnings unless requested, got %v", res.Warnings)
+	}
+}
+
+func TestSearchResultAggregations(t *testing.T) {
+	idx, err := NewMemOnly(NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = idx.Close()
+	}()
+
+	docs := map[string]map[string]interface{}{
+		"a": {"type": "product", "category": "books", "price": 10.0},
+		"b": {"type": "product", "category": "books", "price": 20.0},
+		"c": {"type": "product", "category": "music", "price": 30.0},
+		"d": {"type": "product"},
+	}
+	for id, doc := range docs {
+		err = idx.Index(id, doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fifteen := 15.0
+	ranges := NewFacetRequest("price", 10)
+	ranges.AddNumericRange("cheap", nil, &fifteen)
+	ranges.AddNumericRange("expensive", &fifteen, nil)
+
+	sr := NewSearchRequest(NewMatchQuery("product"))
+	sr.AddFacet("categories", NewFacetRequest("category", 10))
+	sr.AddFacet("prices", NewStatsFacetRequest("price"))
+	sr.AddFacet("ranges", ranges)
+	sr.AddFacet("histogram", NewHistogramFacetRequest("price", 20))
+	res, err := idx.Search(sr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	aggs := res.Aggregations()
+	expected := map[string]*Aggregation{
+		"categories": {
+			Type:    AggregationTerms,
+			Field:   "category",
+			Total:   3,
+			Missing: 1,
+			Buckets: []*AggregationBucket{
+				{Key: "books", Count: 2},
+				{Key: "music", Count: 1},
+			},
+		},
+		"prices": {
+			Type:    AggregationStats,
+			Field:   "price",
+			Total:   3,
+			Missing: 1,
+			Stats:   &search.NumericStats{Count: 3, Min: 10, Max: 30, Sum: 60, Avg: 20},
+		},
+		"ranges": {
+			Type:    AggregationRange,
+			Field:   "price",
+			Total:   3,
+			Missing: 1,
+			Buckets: []*AggregationBucket{
+				{Key: "expensive", From: 15.0, Count: 2},
+				{Key: "cheap", To: 15.0, Count: 1},
+			},
+		},
+		"histogram": {
+			Type:    AggregationHistogram,
+			Field:   "price",
+			Total:   3,
+			Missing: 1,
+			Buckets: []*AggregationBucket{
+				{Key: "0", From: 0.0, To: 20.0, Count: 1},
+				{Key: "20", From: 20.0, To: 40.0, Count: 2},
+			},
+		},
+	}
+	for name, agg := range expected {
+		if !reflect.DeepEqual(aggs[name], agg) {
+			got, _ := json.Marshal(aggs[name])
+			want, _ := json.Marshal(agg)
+			t.Errorf("%s: expected aggregation %s, got %s", name, want, got)
+		}
+	}
+}
+
+func TestSortFieldMultiValueModes(t *testing.T) {
+	idx, err := NewMemOnly(NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = idx.Close()
+	}()
+
+	docs := map[string][]interface{}{
+		"a": {3.0, 1.0},
+		"b": {2.0},
+		"c": {0.0, 4.0, 5.0},
+	}
+	for id, ratings := range docs {
+		err = idx.Index(id, map[string]interface{}{"ratings": ratings})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, test := range []struct {
+		mode     string
+		expected []string
+	}{
+		{mode: "min", expected: []string{"c", "a", "b"}},
+		{mode: "max", expected: []string{"b", "a", "c"}},
+		{mode: "median", expected: []string{"a", "b", "c"}},
+	} {
+		var sr SearchRequest
+		err = json.Unmarshal([]byte(`{"query":{"match_all":{}},`+
+			`"sort":[{"by":"field","field":"ratings","type":"number","mode":"`+test.mode+`"}]}`), &sr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, err := idx.Search(&sr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := make([]string, 0, len(res.Hits))
+		for _, hit := range res.Hits {
+			got = append(got, hit.ID)
+		}
+		if !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("mode %s: expected order %v, got %v", test.mode, test.expected, got)
+		}
+
+		// the mode survives a round trip through JSON
+		buf, err := json.Marshal(sr.Sort)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(buf), `"mode":"`+test.mode+`"`) {
+			t.Errorf("mode %s: expected it in the JSON sort, got %s", test.mode, buf)
+		}
+	}
+
+	for _, input := range []map[string]interface{}{
+		{"by": "field", "field": "ratings", "mode": "average"},
+		{"by": "field", "field": "ratings", "mode": "median", "type": "string"},
+	} {
+		_, err = search.ParseSearchSortObj(input)
+		if err == nil {
+			t.Errorf("expected error parsing sort %v", input)
+		}
+	}
+}